@@ -8,7 +8,7 @@ import Register from './components/register/Register';
 import Login from './components/login/Login';
 import Layout from './components/Layout';
 import RequiredAuth from './components/RequiredAuth';
-import axiosClient from './api/axios.Config';
+import useAxiosPrivate from './hooks/useAxiosPrivate';
 import useAuth from './hooks/useAuth';
 import StreamMovie from './components/stream/StreamMovie';
 
@@ -17,7 +17,8 @@ import {Route, Routes, useNavigate} from 'react-router-dom'
 function App() {
 
   const navigate = useNavigate();
-  const { auth, setAuth } = useAuth();
+  const { setAuth } = useAuth();
+  const axiosPrivate = useAxiosPrivate();
 
   
   const updateMovieReview = (imdb_id) => {
@@ -27,7 +28,8 @@ function App() {
   const handleLogout = async () => {
 
         try {
-            const response = await axiosClient.post("/logout",{user_id: auth.user_id});
+            // The server logs out the session the auth cookie belongs to
+            const response = await axiosPrivate.post("/logout");
             console.log(response.data);
             setAuth(null);
            // localStorage.removeItem('user');
@@ -1,5 +1,6 @@
 import {useState, useEffect} from 'react';
 import axiosClient from '../../api/axios.Config'
+import useAxiosPrivate from '../../hooks/useAxiosPrivate';
 import Movies from '../movies/Movies';
 import Spinner from '../spinner/Spinner';
 
@@ -7,15 +8,24 @@ const Home =({updateMovieReview}) => {
     const [movies, setMovies] = useState([]);
     const [loading, setLoading] = useState(false)
     const [message, setMessage] = useState();
+    const axiosPrivate = useAxiosPrivate();
 
     useEffect(() => {
         const fetchMovies = async () => {
             setLoading(true);
             setMessage("");
             try{
-                const response = await axiosClient.get('/movies');
-                setMovies(response.data);
-                if (response.data.length === 0){
+                // An expired login is refreshed rather than browsed anonymously;
+                // if the session is gone for good, fall back to the public list.
+                let response;
+                try{
+                    response = await axiosPrivate.get('/movies');
+                }catch(error){
+                    if (error.response?.status !== 401) throw error;
+                    response = await axiosClient.get('/movies');
+                }
+                setMovies(response.data.movies);
+                if (response.data.total === 0){
                     setMessage('There are currently no movies available')
                 }
 
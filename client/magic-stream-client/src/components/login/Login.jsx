@@ -12,6 +12,8 @@ const Login = () => {
     const {setAuth}= useAuth();
     const [email, setEmail] = useState('');
     const [password, setPassword] = useState('');
+    const [mfaToken, setMfaToken] = useState(null);
+    const [code, setCode] = useState('');
 
     const [error, setError] = useState(null);
     const [loading, setLoading] = useState(false);
@@ -25,12 +27,23 @@ const Login = () => {
         setError(null);       
 
         try {
-            const response = await axiosClient.post('/login', { email, password });
+            // Second step for accounts with two-factor authentication
+            const response = mfaToken
+                ? await axiosClient.post('/login/mfa', { mfa_token: mfaToken, code })
+                : await axiosClient.post('/login', { email, password });
             console.log(response.data);
             if (response.data.error) {
                 setError(response.data.error);
                 return;
             }
+            if (response.data.content?.mfaRequired) {
+                setMfaToken(response.data.content.mfaToken);
+                return;
+            }
+            if (response.data.content?.mfaEnrollmentRequired) {
+                setError('Your account must set up two-factor authentication before signing in.');
+                return;
+            }
            // console.log(response.data);
             setAuth(response.data);
             
@@ -41,7 +54,7 @@ const Login = () => {
 
         } catch (err) {
             console.error(err);
-            setError('Invalid email or password');
+            setError(mfaToken ? 'Invalid two-factor code' : 'Invalid email or password');
         } finally {
             setLoading(false);
         }
@@ -79,6 +92,22 @@ const Login = () => {
                         />
                     </Form.Group>
 
+                    {mfaToken && (
+                        <Form.Group controlId="formMfaCode" className="mb-3">
+                            <Form.Label>Two-factor code</Form.Label>
+                            <Form.Control
+                                type="text"
+                                inputMode="numeric"
+                                autoComplete="one-time-code"
+                                placeholder="123456"
+                                value={code}
+                                onChange={(e) => setCode(e.target.value)}
+                                required
+                                autoFocus
+                            />
+                        </Form.Group>
+                    )}
+
                     <Button
                         variant="primary"
                         type="submit"
@@ -30,7 +30,6 @@ const Register = () => {
     const handleSubmit = async (e) => {
         e.preventDefault();
         setError(null);
-        const defaultRole = "USER";
 
         if (password !== confirmPassword) {
             setError('Passwords do not match.');
@@ -45,7 +44,6 @@ const Register = () => {
                               lastName: lastName,
                               email: email,
                               password: password,
-                              role: defaultRole,
                               favoriteGenres: favouriteGenres // note camelCase
                             };
             
@@ -41,10 +41,13 @@ const Review = () => {
       setMovie((prev) => ({
         ...prev,
         admin_review: response.data?.admin_review ?? prev.admin_review,
-        ranking: {
-          ranking_name: response.data?.ranking_name ?? prev.ranking?.ranking_name
-        }
+        ranking_status: response.data?.ranking_status
       }));
+
+      // The ranking is computed in the background; poll until the job is done
+      if (response.data?.job_id) {
+        pollRanking(response.data.job_id);
+      }
     } catch (err) {
       console.error('Error updating review:', err);
     } finally {
@@ -52,6 +55,23 @@ const Review = () => {
     }
   };
 
+  const pollRanking = async (jobId) => {
+    for (let i = 0; i < 30; i++) {
+      await new Promise((resolve) => setTimeout(resolve, 2000));
+      try {
+        const { data: job } = await axiosPrivate.get(`/jobs/${jobId}`);
+        if (job.status === 'succeeded' || job.status === 'failed') {
+          const { data } = await axiosPrivate.get(`/movie/${imdb_id}`);
+          setMovie(data);
+          return;
+        }
+      } catch (err) {
+        console.error('Error polling review ranking:', err);
+        return;
+      }
+    }
+  };
+
   if (loading) return <Spinner />;
 
   return (
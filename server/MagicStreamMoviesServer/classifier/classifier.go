@@ -0,0 +1,100 @@
+package classifier
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/models"
+)
+
+// SentimentClassifier maps a free-text admin review onto the name of one of
+// the given rankings. Implementations return ErrUnmatchedRanking rather than
+// a name outside rankings.
+type SentimentClassifier interface {
+	Classify(ctx context.Context, review string, rankings []models.Ranking) (string, error)
+	Name() string
+}
+
+// Supported values for SENTIMENT_BACKEND
+const (
+	BackendOpenAI  = "openai"
+	BackendOllama  = "ollama"
+	BackendLexicon = "lexicon"
+)
+
+// defaultPromptTemplate is used when BASE_PROMPT_TEMPLATE is not set.
+// {rankings} is replaced with the comma-separated ranking names.
+const defaultPromptTemplate = "Classify the sentiment of the following movie review as exactly one of: {rankings}. " +
+	"Reply with that single word only.\nReview: "
+
+var (
+	defaultClassifier SentimentClassifier
+	defaultErr        error
+	defaultOnce       sync.Once
+)
+
+// Default returns the classifier selected by SENTIMENT_BACKEND. The
+// environment is read once, the first time Default is called.
+func Default() (SentimentClassifier, error) {
+	defaultOnce.Do(func() {
+		defaultClassifier, defaultErr = NewFromEnv()
+		if defaultErr == nil {
+			log.Println("Sentiment classifier:", defaultClassifier.Name())
+		}
+	})
+	return defaultClassifier, defaultErr
+}
+
+// NewFromEnv builds a classifier from SENTIMENT_BACKEND (openai, ollama or
+// lexicon; openai when unset) and the backend's own settings. The LLM
+// backends also honour SENTIMENT_JSON_OUTPUT and SENTIMENT_MAX_ATTEMPTS.
+func NewFromEnv() (SentimentClassifier, error) {
+	backend := strings.ToLower(strings.TrimSpace(os.Getenv("SENTIMENT_BACKEND")))
+
+	cfg := PromptConfig{
+		Template:    os.Getenv("BASE_PROMPT_TEMPLATE"),
+		MaxAttempts: 2,
+	}
+	if cfg.Template == "" {
+		cfg.Template = defaultPromptTemplate
+	}
+	if jsonOutput := os.Getenv("SENTIMENT_JSON_OUTPUT"); jsonOutput != "" {
+		val, err := strconv.ParseBool(jsonOutput)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SENTIMENT_JSON_OUTPUT: %w", err)
+		}
+		cfg.JSONOutput = val
+	}
+	if maxAttempts := os.Getenv("SENTIMENT_MAX_ATTEMPTS"); maxAttempts != "" {
+		val, err := strconv.Atoi(maxAttempts)
+		if err != nil || val < 1 {
+			return nil, fmt.Errorf("SENTIMENT_MAX_ATTEMPTS must be a positive integer")
+		}
+		cfg.MaxAttempts = val
+	}
+
+	switch backend {
+	case "", BackendOpenAI:
+		return NewOpenAIClassifier(os.Getenv("OPENAI_API_KEY"), os.Getenv("OPENAI_MODEL"), cfg)
+	case BackendOllama:
+		return NewOllamaClassifier(os.Getenv("OLLAMA_BASE_URL"), os.Getenv("OLLAMA_MODEL"), cfg), nil
+	case BackendLexicon:
+		return NewLexiconClassifier(), nil
+	default:
+		return nil, fmt.Errorf("unknown SENTIMENT_BACKEND %q", backend)
+	}
+}
+
+// buildPrompt fills {rankings} in the template and appends the review.
+func buildPrompt(promptTemplate, review string, rankings []models.Ranking) string {
+	names := make([]string, 0, len(rankings))
+	for _, ranking := range rankings {
+		names = append(names, ranking.RankingName)
+	}
+	return strings.Replace(promptTemplate, "{rankings}", strings.Join(names, ","), 1) + review
+}
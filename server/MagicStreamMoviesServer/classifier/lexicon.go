@@ -0,0 +1,127 @@
+package classifier
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/models"
+)
+
+var positiveWords = []string{
+	"amazing", "awesome", "beautiful", "best", "brilliant", "captivating", "charming", "classic",
+	"compelling", "delightful", "enjoy", "enjoyable", "enjoyed", "engaging", "entertaining",
+	"excellent", "exceptional", "fantastic", "fun", "funny", "good", "gorgeous", "great",
+	"gripping", "hilarious", "impressive", "incredible", "love", "loved", "masterpiece",
+	"memorable", "moving", "outstanding", "perfect", "phenomenal", "powerful", "recommend",
+	"remarkable", "riveting", "solid", "stunning", "superb", "thrilling", "touching",
+	"wonderful", "worth",
+}
+
+var negativeWords = []string{
+	"annoying", "awful", "bad", "bland", "boring", "cheap", "clumsy", "confusing", "disappointing",
+	"disappointment", "dreadful", "dull", "forgettable", "hate", "hated", "horrible", "lame",
+	"mediocre", "mess", "messy", "pointless", "poor", "predictable", "ridiculous", "shallow",
+	"silly", "slow", "stupid", "tedious", "terrible", "tiresome", "ugly", "unfunny",
+	"uninspired", "waste", "weak", "worse", "worst",
+}
+
+// strongWords count double, so "excellent" outranks "good".
+var strongWords = map[string]bool{
+	"amazing": true, "brilliant": true, "exceptional": true, "excellent": true, "fantastic": true,
+	"incredible": true, "masterpiece": true, "outstanding": true, "perfect": true, "phenomenal": true,
+	"superb": true, "awful": true, "dreadful": true, "horrible": true, "terrible": true,
+	"waste": true, "worst": true,
+}
+
+var negations = map[string]bool{
+	"not": true, "no": true, "never": true, "hardly": true, "isn't": true, "wasn't": true,
+	"aren't": true, "don't": true, "doesn't": true, "didn't": true, "nothing": true,
+}
+
+var intensifiers = map[string]float64{
+	"very": 1.5, "really": 1.5, "extremely": 2, "incredibly": 2, "truly": 1.5, "so": 1.3,
+	"absolutely": 2, "utterly": 2, "quite": 1.2, "slightly": 0.5, "somewhat": 0.7,
+}
+
+// LexiconClassifier is an offline, rule-based classifier. It needs no
+// network access, which makes it suitable for development and CI.
+//
+// Each review gets a score between -1 and 1 from a word list with simple
+// negation and intensifier handling. The score is then spread evenly across
+// the rankings ordered by ranking_value, where the lowest value is the best.
+type LexiconClassifier struct {
+	weights map[string]float64
+}
+
+func NewLexiconClassifier() *LexiconClassifier {
+	weights := make(map[string]float64, len(positiveWords)+len(negativeWords))
+	for _, w := range positiveWords {
+		weights[w] = 1
+	}
+	for _, w := range negativeWords {
+		weights[w] = -1
+	}
+	for w := range strongWords {
+		weights[w] *= 2
+	}
+	return &LexiconClassifier{weights: weights}
+}
+
+func (l *LexiconClassifier) Name() string {
+	return BackendLexicon
+}
+
+func (l *LexiconClassifier) Classify(ctx context.Context, review string, rankings []models.Ranking) (string, error) {
+	if len(rankings) == 0 {
+		return "", errors.New("no rankings to classify against")
+	}
+
+	ordered := append([]models.Ranking(nil), rankings...)
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].RankingValue < ordered[j].RankingValue })
+
+	score := l.Score(review)
+	// score 1 -> first (best) ranking, score -1 -> last (worst) ranking
+	index := int(math.Round((1 - score) / 2 * float64(len(ordered)-1)))
+
+	return ordered[index].RankingName, nil
+}
+
+// Score returns the review's sentiment between -1 (negative) and 1 (positive).
+// A review with no sentiment-bearing words scores 0.
+func (l *LexiconClassifier) Score(review string) float64 {
+	words := strings.FieldsFunc(strings.ToLower(review), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+
+	var total, magnitude float64
+	for i, word := range words {
+		weight, ok := l.weights[word]
+		if !ok {
+			continue
+		}
+
+		// Look at up to three preceding words for negations and intensifiers,
+		// e.g. "not very good" or "really not bad".
+		for j := i - 1; j >= 0 && j >= i-3; j-- {
+			if negations[words[j]] {
+				weight = -weight * 0.75
+			} else if factor, ok := intensifiers[words[j]]; ok {
+				weight *= factor
+			}
+		}
+
+		total += weight
+		magnitude += math.Abs(weight)
+	}
+
+	if magnitude == 0 {
+		return 0
+	}
+	// A single plain word ("good") lands half way, a single strong word or
+	// several agreeing words saturate, and mixed reviews drift to the middle.
+	return max(-1, min(1, total/max(2, 0.75*magnitude)))
+}
@@ -0,0 +1,94 @@
+package classifier
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/models"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/utils"
+)
+
+// ErrUnmatchedRanking is returned when a classifier's answer cannot be
+// mapped onto any ranking. Callers must not persist a ranking in that case.
+var ErrUnmatchedRanking = errors.New("classifier response does not match any ranking")
+
+// minFuzzyRankingSimilarity is the edit similarity needed to accept a
+// misspelled ranking such as "Excelent".
+const minFuzzyRankingSimilarity = 0.75
+
+// MatchRanking maps a raw classifier response onto one of rankings.
+//
+// The response may be a bare word with stray whitespace, punctuation or
+// casing ("Excellent.", " excellent\n"), a JSON object such as
+// {"ranking": "Excellent"}, a short sentence naming exactly one ranking, or
+// a slightly misspelled ranking name.
+func MatchRanking(response string, rankings []models.Ranking) (models.Ranking, error) {
+	if answer, ok := rankingFromJSON(response); ok {
+		response = answer
+	}
+
+	cleaned := utils.NormalizeText(response)
+	if cleaned == "" {
+		return models.Ranking{}, fmt.Errorf("%w: empty response", ErrUnmatchedRanking)
+	}
+
+	normalized := make([]string, len(rankings))
+	for i, ranking := range rankings {
+		normalized[i] = utils.NormalizeText(ranking.RankingName)
+		if normalized[i] == cleaned {
+			return ranking, nil
+		}
+	}
+
+	// A sentence that mentions exactly one ranking, e.g. "The sentiment is excellent"
+	padded := " " + cleaned + " "
+	mentioned := -1
+	for i, name := range normalized {
+		if name != "" && strings.Contains(padded, " "+name+" ") {
+			if mentioned >= 0 {
+				mentioned = -1
+				break
+			}
+			mentioned = i
+		}
+	}
+	if mentioned >= 0 {
+		return rankings[mentioned], nil
+	}
+
+	// Typos: take the closest name if it is close enough and not tied
+	best, bestScore, tied := -1, 0.0, false
+	for i, name := range normalized {
+		score := utils.EditSimilarity(cleaned, name)
+		if score > bestScore {
+			best, bestScore, tied = i, score, false
+		} else if score == bestScore {
+			tied = true
+		}
+	}
+	if best >= 0 && !tied && bestScore >= minFuzzyRankingSimilarity {
+		return rankings[best], nil
+	}
+
+	return models.Ranking{}, fmt.Errorf("%w: %q", ErrUnmatchedRanking, strings.TrimSpace(response))
+}
+
+// rankingFromJSON extracts the "ranking" field from a JSON object anywhere
+// in the response, which is what the structured-output prompt asks for.
+func rankingFromJSON(response string) (string, bool) {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end <= start {
+		return "", false
+	}
+
+	var payload struct {
+		Ranking string `json:"ranking"`
+	}
+	if err := json.Unmarshal([]byte(response[start:end+1]), &payload); err != nil || payload.Ranking == "" {
+		return "", false
+	}
+	return payload.Ranking, true
+}
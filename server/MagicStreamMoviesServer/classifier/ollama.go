@@ -0,0 +1,88 @@
+package classifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/models"
+)
+
+// OllamaClassifier calls an Ollama-compatible /api/generate endpoint, which
+// lets reviews be ranked by a model running on the local machine.
+type OllamaClassifier struct {
+	baseURL    string
+	model      string
+	cfg        PromptConfig
+	httpClient *http.Client
+}
+
+// NewOllamaClassifier defaults to http://localhost:11434 and the llama3 model.
+func NewOllamaClassifier(baseURL, model string, cfg PromptConfig) *OllamaClassifier {
+	if baseURL == "" {
+		baseURL = "http://localhost:11434"
+	}
+	if model == "" {
+		model = "llama3"
+	}
+
+	return &OllamaClassifier{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		model:      model,
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: 90 * time.Second},
+	}
+}
+
+func (o *OllamaClassifier) Name() string {
+	return BackendOllama + " (" + o.model + ")"
+}
+
+func (o *OllamaClassifier) Classify(ctx context.Context, review string, rankings []models.Ranking) (string, error) {
+	return classifyWithLLM(ctx, o.complete, o.cfg, review, rankings)
+}
+
+func (o *OllamaClassifier) complete(ctx context.Context, prompt string, jsonOutput bool) (string, error) {
+	body := map[string]any{
+		"model":  o.model,
+		"prompt": prompt,
+		"stream": false,
+	}
+	if jsonOutput {
+		body["format"] = "json"
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/api/generate", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("ollama returned %s", resp.Status)
+	}
+
+	var result struct {
+		Response string `json:"response"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+
+	return result.Response, nil
+}
@@ -0,0 +1,52 @@
+package classifier
+
+import (
+	"context"
+	"errors"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/models"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/openai"
+)
+
+// OpenAIClassifier asks an OpenAI chat model to pick the ranking.
+type OpenAIClassifier struct {
+	llm *openai.LLM
+	cfg PromptConfig
+}
+
+// NewOpenAIClassifier creates the OpenAI client once so it can be reused
+// across requests. An empty model uses the library default.
+func NewOpenAIClassifier(apiKey, model string, cfg PromptConfig) (*OpenAIClassifier, error) {
+	if apiKey == "" {
+		return nil, errors.New("could not read OPENAI_API_KEY")
+	}
+
+	opts := []openai.Option{openai.WithToken(apiKey)}
+	if model != "" {
+		opts = append(opts, openai.WithModel(model))
+	}
+
+	llm, err := openai.New(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OpenAIClassifier{llm: llm, cfg: cfg}, nil
+}
+
+func (o *OpenAIClassifier) Name() string {
+	return BackendOpenAI
+}
+
+func (o *OpenAIClassifier) Classify(ctx context.Context, review string, rankings []models.Ranking) (string, error) {
+	return classifyWithLLM(ctx, o.complete, o.cfg, review, rankings)
+}
+
+func (o *OpenAIClassifier) complete(ctx context.Context, prompt string, jsonOutput bool) (string, error) {
+	var opts []llms.CallOption
+	if jsonOutput {
+		opts = append(opts, llms.WithJSONMode())
+	}
+	return o.llm.Call(ctx, prompt, opts...)
+}
@@ -0,0 +1,70 @@
+package classifier
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/models"
+)
+
+// PromptConfig controls how the LLM-backed classifiers prompt the model.
+type PromptConfig struct {
+	// Template is the base prompt. {rankings} is replaced with the
+	// comma-separated ranking names and the review is appended.
+	Template string
+	// JSONOutput asks the model for {"ranking": "..."} instead of a bare word.
+	JSONOutput bool
+	// MaxAttempts is the number of calls made, including corrective retries.
+	MaxAttempts int
+}
+
+// completeFunc sends a prompt to a model and returns its raw answer.
+type completeFunc func(ctx context.Context, prompt string, jsonOutput bool) (string, error)
+
+// classifyWithLLM prompts the model and normalizes its answer with
+// MatchRanking. When the answer matches no ranking, the model is asked again
+// with a corrective prompt, up to cfg.MaxAttempts calls in total.
+func classifyWithLLM(ctx context.Context, complete completeFunc, cfg PromptConfig, review string, rankings []models.Ranking) (string, error) {
+	names := rankingNames(rankings)
+
+	prompt := buildPrompt(cfg.Template, review, rankings)
+	if cfg.JSONOutput {
+		prompt += fmt.Sprintf("\n\nRespond only with a JSON object of the form {\"ranking\": \"<one of %s>\"}.", names)
+	}
+
+	attempts := max(cfg.MaxAttempts, 1)
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		response, err := complete(ctx, prompt, cfg.JSONOutput)
+		if err != nil {
+			return "", err
+		}
+
+		ranking, err := MatchRanking(response, rankings)
+		if err == nil {
+			return ranking.RankingName, nil
+		}
+
+		lastErr = err
+		log.Printf("Classifier attempt %d/%d unmatched: %q", attempt, attempts, response)
+
+		prompt = buildPrompt(cfg.Template, review, rankings) + fmt.Sprintf(
+			"\n\nYour previous answer %q was not valid. Answer with exactly one of these words and nothing else: %s.",
+			strings.TrimSpace(response), names)
+		if cfg.JSONOutput {
+			prompt += fmt.Sprintf(" Use the JSON form {\"ranking\": \"<one of %s>\"}.", names)
+		}
+	}
+
+	return "", lastErr
+}
+
+func rankingNames(rankings []models.Ranking) string {
+	names := make([]string, 0, len(rankings))
+	for _, ranking := range rankings {
+		names = append(names, ranking.RankingName)
+	}
+	return strings.Join(names, ", ")
+}
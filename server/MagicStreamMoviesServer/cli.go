@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/controllers"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/database"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// runCommand dispatches the administrative subcommands that can be run
+// instead of the HTTP server, e.g. `go run . import -file movies.csv`.
+func runCommand(args []string) {
+	switch args[0] {
+	case "import":
+		runImport(args[1:])
+	case "rerank":
+		runRerank(args[1:])
+	default:
+		log.Fatalf("Unknown command %q (available: import, rerank)", args[0])
+	}
+}
+
+func runImport(args []string) {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	filePath := flags.String("file", "", "CSV or JSON Lines file of movies to import")
+	format := flags.String("format", "", "csv or jsonl (detected from the file extension if empty)")
+	dryRun := flags.Bool("dry-run", false, "report what would change without writing")
+	flags.Parse(args)
+
+	if *filePath == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	importFormat, err := controllers.DetectImportFormat(*format, *filePath, "")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	file, err := os.Open(*filePath)
+	if err != nil {
+		log.Fatalf("Unable to open %s: %v", *filePath, err)
+	}
+	defer file.Close()
+
+	client := database.Connect()
+	defer client.Disconnect(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	report, err := controllers.ImportMovies(ctx, client, file, importFormat, *dryRun, "cli")
+	if err != nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Import finished: %d created, %d updated, %d rejected (dry run: %t)",
+		report.Created, report.Updated, report.Rejected, report.DryRun)
+}
+
+// runRerank re-classifies the whole catalog in the foreground. Interrupting
+// it with Ctrl-C keeps the last checkpoint, and -resume picks it up again.
+func runRerank(args []string) {
+	defaults := controllers.DefaultRerankOptions()
+
+	flags := flag.NewFlagSet("rerank", flag.ExitOnError)
+	resume := flags.String("resume", "", "id of an unfinished run to resume from its checkpoint")
+	concurrency := flags.Int("concurrency", defaults.Concurrency, "maximum classifier calls in flight")
+	rate := flags.Float64("rate", defaults.RatePerSec, "maximum classifier calls per second (0 for no limit)")
+	flags.Parse(args)
+
+	client := database.Connect()
+	defer client.Disconnect(context.Background())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var runId primitive.ObjectID
+	if *resume != "" {
+		id, err := primitive.ObjectIDFromHex(*resume)
+		if err != nil {
+			log.Fatalf("Invalid run id %q", *resume)
+		}
+		runId = id
+	} else {
+		opts := controllers.RerankOptions{Concurrency: *concurrency, RatePerSec: *rate}
+		run, err := controllers.CreateRerankRun(ctx, client, opts)
+		if err != nil {
+			if errors.Is(err, controllers.ErrRerankInProgress) {
+				log.Fatalf("Run %s is still in progress; resume it with -resume %s", run.ID.Hex(), run.ID.Hex())
+			}
+			log.Fatalf("Failed to create re-rank run: %v", err)
+		}
+		runId = run.ID
+	}
+
+	log.Println("Re-rank run:", runId.Hex())
+
+	run, err := controllers.RerankCatalog(ctx, client, runId)
+	if err != nil {
+		log.Fatalf("Re-rank stopped: %v (resume with -resume %s)", err, runId.Hex())
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(run); err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Re-rank finished: %d processed, %d changed, %d errors",
+		run.Processed, len(run.Changes), len(run.Errors))
+}
@@ -0,0 +1,336 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/database"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/mailer"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/models"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/throttle"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Password reset and email verification both work by emailing a link with a
+// single-use token. The endpoints that send those emails answer the same way
+// whether or not the address has an account.
+
+const emailSentMessage = "If an account exists for that address, an email is on its way."
+
+// mailPolicy caps how many account emails one address can trigger, so the
+// endpoints cannot be used to flood someone's inbox.
+var mailPolicy = throttle.Policy{
+	MaxFailures: 5,
+	Window:      time.Hour,
+	Lockout:     time.Hour,
+}
+
+func passwordResetTTL() time.Duration {
+	return time.Duration(utils.EnvInt("PASSWORD_RESET_TTL_MINUTES", 60)) * time.Minute
+}
+
+func emailVerificationTTL() time.Duration {
+	return time.Duration(utils.EnvInt("EMAIL_VERIFICATION_TTL_MINUTES", 48*60)) * time.Minute
+}
+
+// emailVerificationRequired reports whether REQUIRE_EMAIL_VERIFICATION keeps
+// unverified accounts from logging in.
+func emailVerificationRequired() bool {
+	required, _ := strconv.ParseBool(os.Getenv("REQUIRE_EMAIL_VERIFICATION"))
+	return required
+}
+
+// mailThrottleKey is the throttle key limiting account emails to an address.
+func mailThrottleKey(email string) string {
+	return "mail:" + strings.ToLower(email)
+}
+
+// appLink builds a link into the web client from APP_BASE_URL.
+func appLink(path, token string) string {
+	base := os.Getenv("APP_BASE_URL")
+	if base == "" {
+		base = "http://localhost:5173"
+	}
+	return strings.TrimRight(base, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+// sendAccountEmail issues a token and mails the link in the background, so
+// the response time does not depend on whether an email was sent.
+func sendAccountEmail(client *mongo.Client, userId, email, purpose string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+
+		status, err := throttle.Default().RecordFailure(ctx, mailThrottleKey(email), mailPolicy)
+		if err == nil && status.Locked && !status.JustLocked {
+			log.Println("Not sending", purpose, "email: too many requests for", email)
+			return
+		}
+
+		var msg mailer.Message
+		var token string
+		switch purpose {
+		case models.UserTokenPasswordReset:
+			token, err = utils.IssueUserToken(ctx, client, userId, purpose, email, passwordResetTTL())
+			msg = mailer.Message{
+				To:      email,
+				Subject: "Reset your MagicStream password",
+				Body: fmt.Sprintf("Someone asked to reset the password for your MagicStream account.\n\n"+
+					"Open this link within %d minutes to choose a new password:\n%s\n\n"+
+					"If it wasn't you, you can ignore this email.",
+					int(passwordResetTTL().Minutes()), appLink("/reset-password", token)),
+			}
+		case models.UserTokenEmailVerification:
+			token, err = utils.IssueUserToken(ctx, client, userId, purpose, email, emailVerificationTTL())
+			msg = mailer.Message{
+				To:      email,
+				Subject: "Confirm your MagicStream email address",
+				Body: fmt.Sprintf("Confirm this address for your MagicStream account by opening:\n%s\n\n"+
+					"If you didn't sign up, you can ignore this email.",
+					appLink("/verify-email", token)),
+			}
+		}
+		if err != nil {
+			log.Println("Failed to issue", purpose, "token:", err)
+			return
+		}
+		m, err := mailer.Default()
+		if err == nil {
+			err = m.Send(ctx, msg)
+		}
+		if err != nil {
+			log.Println("Failed to send", purpose, "email to", email+":", err)
+		}
+	}()
+}
+
+// sendEmailChangeNotice tells the current address that a change to another
+// one was requested, so the owner notices if it wasn't them.
+func sendEmailChangeNotice(oldEmail, newEmail string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+
+		status, err := throttle.Default().RecordFailure(ctx, mailThrottleKey(oldEmail), mailPolicy)
+		if err == nil && status.Locked && !status.JustLocked {
+			log.Println("Not sending email change notice: too many requests for", oldEmail)
+			return
+		}
+
+		msg := mailer.Message{
+			To:      oldEmail,
+			Subject: "Your MagicStream email address is being changed",
+			Body: fmt.Sprintf("Someone asked to change the email address of your MagicStream account to %s.\n\n"+
+				"The change takes effect once the new address is confirmed. "+
+				"If it wasn't you, change your password and log out your other devices.",
+				newEmail),
+		}
+		m, err := mailer.Default()
+		if err == nil {
+			err = m.Send(ctx, msg)
+		}
+		if err != nil {
+			log.Println("Failed to send email change notice to", oldEmail+":", err)
+		}
+	}()
+}
+
+// ForgotPassword emails a password reset link.
+func ForgotPassword(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var req struct {
+			Email string `json:"email" validate:"required,email"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || validate.Struct(req) != nil {
+			accountReply(c, http.StatusBadRequest, "A valid email is required")
+			return
+		}
+
+		var user models.User
+		err := database.OpenCollection("users", client).FindOne(ctx, bson.M{"email": req.Email}).Decode(&user)
+		if err == nil {
+			sendAccountEmail(client, user.UserID, user.Email, models.UserTokenPasswordReset)
+		} else if err != mongo.ErrNoDocuments {
+			log.Println("Forgot password lookup failed:", err)
+		}
+
+		accountReply(c, http.StatusAccepted, emailSentMessage)
+	}
+}
+
+// ResetPassword sets a new password from a reset token. Every session is
+// logged out, since whoever knew the old password may still hold one.
+func ResetPassword(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var req struct {
+			Token    string `json:"token" validate:"required"`
+			Password string `json:"password" validate:"required,min=8,max=20"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			accountReply(c, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			accountReply(c, http.StatusBadRequest, "Validation failed: "+err.Error())
+			return
+		}
+
+		hashedPassword, err := HashPassword(req.Password)
+		if err != nil {
+			accountReply(c, http.StatusInternalServerError, "Unable to hash password")
+			return
+		}
+
+		userToken, err := utils.ConsumeUserToken(ctx, client, req.Token, models.UserTokenPasswordReset)
+		if err != nil {
+			if err == utils.ErrInvalidUserToken {
+				accountReply(c, http.StatusBadRequest, "This reset link is invalid or has expired")
+				return
+			}
+			accountReply(c, http.StatusInternalServerError, "Failed to reset password")
+			return
+		}
+
+		// The reset email proves the user owns the address it went to
+		set := bson.M{"password": hashedPassword, "updated_at": time.Now()}
+		var user models.User
+		err = database.OpenCollection("users", client).FindOne(ctx, bson.M{"user_id": userToken.UserID}).Decode(&user)
+		if err != nil {
+			accountReply(c, http.StatusBadRequest, "This reset link is invalid or has expired")
+			return
+		}
+		if user.Email == userToken.Email {
+			set["email_verified"] = true
+		}
+
+		_, err = database.OpenCollection("users", client).UpdateOne(ctx,
+			bson.M{"user_id": userToken.UserID},
+			bson.M{"$set": set, "$inc": bson.M{"token_version": 1}},
+		)
+		if err != nil {
+			accountReply(c, http.StatusInternalServerError, "Failed to reset password")
+			return
+		}
+
+		if err := utils.RevokeAllSessions(ctx, client, userToken.UserID, models.SessionRevokedPasswordReset); err != nil {
+			log.Println("Failed to revoke sessions after password reset:", err)
+		}
+		resetLoginFailures(ctx, user.Email)
+
+		accountReply(c, http.StatusOK, "Password has been reset. Please log in.")
+	}
+}
+
+// VerifyEmail marks the address a verification token was sent to as
+// verified, provided the account still uses it. If the address is the
+// account's pending new email, the change is applied.
+func VerifyEmail(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var req struct {
+			Token string `json:"token"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || req.Token == "" {
+			accountReply(c, http.StatusBadRequest, "token is required")
+			return
+		}
+
+		userToken, err := utils.ConsumeUserToken(ctx, client, req.Token, models.UserTokenEmailVerification)
+		if err != nil {
+			if err == utils.ErrInvalidUserToken {
+				accountReply(c, http.StatusBadRequest, "This verification link is invalid or has expired")
+				return
+			}
+			accountReply(c, http.StatusInternalServerError, "Failed to verify email")
+			return
+		}
+
+		userCollection := database.OpenCollection("users", client)
+		result, err := userCollection.UpdateOne(ctx,
+			bson.M{"user_id": userToken.UserID, "email": userToken.Email},
+			bson.M{"$set": bson.M{"email_verified": true, "updated_at": time.Now()}},
+		)
+		if err == nil && result.MatchedCount == 0 {
+			result, err = userCollection.UpdateOne(ctx,
+				bson.M{"user_id": userToken.UserID, "pending_email": userToken.Email},
+				bson.M{
+					"$set":   bson.M{"email": userToken.Email, "email_verified": true, "updated_at": time.Now()},
+					"$unset": bson.M{"pending_email": ""},
+				},
+			)
+		}
+		if err != nil {
+			// Unique index on email: someone else registered it meanwhile
+			if mongo.IsDuplicateKeyError(err) {
+				accountReply(c, http.StatusConflict, "That email address is already in use")
+				return
+			}
+			accountReply(c, http.StatusInternalServerError, "Failed to verify email")
+			return
+		}
+		if result.MatchedCount == 0 {
+			accountReply(c, http.StatusBadRequest, "This verification link is for an address no longer on the account")
+			return
+		}
+
+		accountReply(c, http.StatusOK, "Email address verified")
+	}
+}
+
+// ResendVerification emails a new verification link to an unverified
+// account.
+func ResendVerification(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var req struct {
+			Email string `json:"email" validate:"required,email"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || validate.Struct(req) != nil {
+			accountReply(c, http.StatusBadRequest, "A valid email is required")
+			return
+		}
+
+		var user models.User
+		err := database.OpenCollection("users", client).FindOne(ctx, bson.M{"email": req.Email}).Decode(&user)
+		if err == nil && !user.EmailVerified {
+			sendAccountEmail(client, user.UserID, user.Email, models.UserTokenEmailVerification)
+		} else if err != nil && err != mongo.ErrNoDocuments {
+			log.Println("Resend verification lookup failed:", err)
+		}
+
+		accountReply(c, http.StatusAccepted, emailSentMessage)
+	}
+}
+
+func accountReply(c *gin.Context, code int, message string) {
+	status := "success"
+	if code >= http.StatusBadRequest {
+		status = "error"
+	}
+	c.JSON(code, models.APIResponse{
+		Status:    status,
+		Error:     code >= http.StatusBadRequest,
+		Message:   message,
+		Timestamp: time.Now(),
+	})
+}
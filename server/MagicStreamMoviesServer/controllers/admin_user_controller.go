@@ -0,0 +1,322 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/database"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/models"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ListUsers pages through accounts for admins. ?q= matches a substring of
+// the email or either name; ?role= and ?disabled= filter further.
+func ListUsers(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		page, pageSize, err := parsePagination(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		filter := bson.D{}
+		if q := strings.TrimSpace(c.Query("q")); q != "" {
+			pattern := containsFold(q)
+			filter = append(filter, bson.E{Key: "$or", Value: bson.A{
+				bson.M{"email": pattern},
+				bson.M{"first_name": pattern},
+				bson.M{"last_name": pattern},
+			}})
+		}
+		if role := c.Query("role"); role != "" {
+			filter = append(filter, bson.E{Key: "role", Value: strings.ToUpper(role)})
+		}
+		if disabledStr := c.Query("disabled"); disabledStr != "" {
+			disabled, err := strconv.ParseBool(disabledStr)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "disabled must be true or false"})
+				return
+			}
+			if disabled {
+				filter = append(filter, bson.E{Key: "disabled", Value: true})
+			} else {
+				filter = append(filter, bson.E{Key: "disabled", Value: bson.M{"$ne": true}})
+			}
+		}
+
+		userCollection := database.OpenCollection("users", client)
+
+		total, err := userCollection.CountDocuments(ctx, filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count users."})
+			return
+		}
+
+		findOptions := options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "user_id", Value: 1}}).
+			SetSkip((page - 1) * pageSize).
+			SetLimit(pageSize)
+
+		cursor, err := userCollection.Find(ctx, filter, findOptions)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users."})
+			return
+		}
+		defer cursor.Close(ctx)
+
+		var users []models.User
+		if err := cursor.All(ctx, &users); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode users."})
+			return
+		}
+
+		summaries := make([]models.UserSummary, 0, len(users))
+		for i := range users {
+			summaries = append(summaries, models.NewUserSummary(&users[i]))
+		}
+
+		totalPages := (total + pageSize - 1) / pageSize
+
+		response := models.UserPage{
+			Users:      summaries,
+			Total:      total,
+			Page:       page,
+			PageSize:   pageSize,
+			TotalPages: totalPages,
+		}
+		if page < totalPages {
+			response.Next = pageLink(c, page+1)
+		}
+		if page > 1 {
+			response.Prev = pageLink(c, min(page-1, max(totalPages, 1)))
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// GetUser returns one account for admins.
+func GetUser(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var user models.User
+		err := database.OpenCollection("users", client).FindOne(ctx, bson.M{"user_id": c.Param("user_id")}).Decode(&user)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+			return
+		}
+
+		c.JSON(http.StatusOK, models.NewUserSummary(&user))
+	}
+}
+
+// SetUserRole changes an account's role. The role is baked into access
+// tokens, so the user is logged out everywhere for it to take effect.
+func SetUserRole(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var req struct {
+			Role string `json:"role"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+			return
+		}
+		role := strings.ToUpper(strings.TrimSpace(req.Role))
+		if !utils.IsKnownRole(role) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown role " + req.Role})
+			return
+		}
+
+		userId := c.Param("user_id")
+		if userId == c.GetString("userId") {
+			c.JSON(http.StatusConflict, gin.H{"error": "You cannot change your own role"})
+			return
+		}
+
+		var before models.User
+		err := database.OpenCollection("users", client).FindOneAndUpdate(ctx,
+			bson.M{"user_id": userId},
+			bson.M{"$set": bson.M{"role": role, "updated_at": time.Now()}},
+		).Decode(&before)
+		if err != nil {
+			adminUserError(c, err, "Failed to change role")
+			return
+		}
+
+		if before.Role != role {
+			if !forceLogout(c, ctx, client, userId) {
+				return
+			}
+			recordAuthAudit(ctx, client, models.AuthAudit{
+				Event:   models.AuthAuditRoleChange,
+				Key:     "user:" + userId,
+				Email:   before.Email,
+				ActorID: c.GetString("userId"),
+				Detail:  before.Role + " -> " + role,
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Role updated", "user_id": userId, "role": role})
+	}
+}
+
+// DisableUser blocks an account from logging in and ends all its sessions.
+func DisableUser(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var req struct {
+			Reason string `json:"reason"`
+		}
+		// The body is optional
+		_ = c.ShouldBindJSON(&req)
+
+		userId := c.Param("user_id")
+		if userId == c.GetString("userId") {
+			c.JSON(http.StatusConflict, gin.H{"error": "You cannot disable your own account"})
+			return
+		}
+
+		now := time.Now()
+		set := bson.M{"disabled": true, "disabled_at": now, "updated_at": now}
+		if reason := strings.TrimSpace(req.Reason); reason != "" {
+			set["disabled_reason"] = reason
+		}
+
+		var before models.User
+		err := database.OpenCollection("users", client).FindOneAndUpdate(ctx,
+			bson.M{"user_id": userId},
+			bson.M{"$set": set},
+		).Decode(&before)
+		if err != nil {
+			adminUserError(c, err, "Failed to disable user")
+			return
+		}
+
+		if !forceLogout(c, ctx, client, userId) {
+			return
+		}
+		if !before.Disabled {
+			recordAuthAudit(ctx, client, models.AuthAudit{
+				Event:   models.AuthAuditDisable,
+				Key:     "user:" + userId,
+				Email:   before.Email,
+				ActorID: c.GetString("userId"),
+				Detail:  strings.TrimSpace(req.Reason),
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "User disabled", "user_id": userId})
+	}
+}
+
+// EnableUser lifts DisableUser.
+func EnableUser(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		userId := c.Param("user_id")
+
+		var before models.User
+		err := database.OpenCollection("users", client).FindOneAndUpdate(ctx,
+			bson.M{"user_id": userId},
+			bson.M{
+				"$set":   bson.M{"updated_at": time.Now()},
+				"$unset": bson.M{"disabled": "", "disabled_at": "", "disabled_reason": ""},
+			},
+		).Decode(&before)
+		if err != nil {
+			adminUserError(c, err, "Failed to enable user")
+			return
+		}
+
+		if before.Disabled {
+			recordAuthAudit(ctx, client, models.AuthAudit{
+				Event:   models.AuthAuditEnable,
+				Key:     "user:" + userId,
+				Email:   before.Email,
+				ActorID: c.GetString("userId"),
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "User enabled", "user_id": userId})
+	}
+}
+
+// ForceLogoutUser ends every session of an account.
+func ForceLogoutUser(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		userId := c.Param("user_id")
+
+		var user models.User
+		err := database.OpenCollection("users", client).FindOne(ctx, bson.M{"user_id": userId}).Decode(&user)
+		if err != nil {
+			adminUserError(c, err, "Failed to log user out")
+			return
+		}
+
+		if !forceLogout(c, ctx, client, userId) {
+			return
+		}
+		recordAuthAudit(ctx, client, models.AuthAudit{
+			Event:   models.AuthAuditForceLogout,
+			Key:     "user:" + userId,
+			Email:   user.Email,
+			ActorID: c.GetString("userId"),
+		})
+
+		c.JSON(http.StatusOK, gin.H{"message": "User logged out of all devices", "user_id": userId})
+	}
+}
+
+// forceLogout invalidates all of the user's tokens and sessions. On failure
+// it writes the error response and returns false.
+func forceLogout(c *gin.Context, ctx context.Context, client *mongo.Client, userId string) bool {
+	if err := utils.BumpTokenVersion(ctx, client, userId); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log user out"})
+		return false
+	}
+	if err := utils.RevokeAllSessions(ctx, client, userId, models.SessionRevokedByAdmin); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log user out"})
+		return false
+	}
+	return true
+}
+
+func adminUserError(c *gin.Context, err error, message string) {
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
+
+// containsFold matches values containing s, ignoring case.
+func containsFold(s string) bson.M {
+	return bson.M{"$regex": regexp.QuoteMeta(s), "$options": "i"}
+}
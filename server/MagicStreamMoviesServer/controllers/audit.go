@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/database"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// recordMovieAudit writes a before/after snapshot of a movie change to the
+// movie_audit collection. The change itself has already been applied, so a
+// failure here is logged rather than surfaced to the caller.
+func recordMovieAudit(ctx context.Context, client *mongo.Client, action, imdbID, actorID string, before, after *models.Movie) {
+	auditCollection := database.OpenCollection("movie_audit", client)
+
+	entry := models.MovieAudit{
+		ImdbID:    imdbID,
+		Action:    action,
+		ActorID:   actorID,
+		Before:    before,
+		After:     after,
+		CreatedAt: time.Now(),
+	}
+
+	if _, err := auditCollection.InsertOne(ctx, entry); err != nil {
+		log.Println("Failed to record movie audit:", action, imdbID, err)
+	}
+}
+
+// recordAuthAudit writes a security event to the auth_audit collection.
+// Like recordMovieAudit it only logs failures.
+func recordAuthAudit(ctx context.Context, client *mongo.Client, entry models.AuthAudit) {
+	entry.CreatedAt = time.Now()
+
+	if _, err := database.OpenCollection("auth_audit", client).InsertOne(ctx, entry); err != nil {
+		log.Println("Failed to record auth audit:", entry.Event, entry.Key, err)
+	}
+}
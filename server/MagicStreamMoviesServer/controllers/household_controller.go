@@ -0,0 +1,347 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/database"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/models"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxProfiles caps the household profiles on one account.
+const maxProfiles = 6
+
+// ListProfiles returns the logged-in account's household profiles.
+func ListProfiles(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		user, ok := profileOwner(c, ctx, client)
+		if !ok {
+			return
+		}
+
+		profiles := user.Profiles
+		if profiles == nil {
+			profiles = []models.Profile{}
+		}
+		c.JSON(http.StatusOK, gin.H{"profiles": profiles, "current": c.GetString("profileId")})
+	}
+}
+
+// CreateProfile adds a household profile. Its favourite genres default to
+// the account's.
+func CreateProfile(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var req models.ProfileInput
+		if !bindProfileInput(c, &req) {
+			return
+		}
+		if req.Name == nil || strings.TrimSpace(*req.Name) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+			return
+		}
+
+		user, ok := profileOwner(c, ctx, client)
+		if !ok {
+			return
+		}
+		// A new profile could be less restricted than the one in use
+		if !checkParentalPIN(c, ctx, user, req.PIN) {
+			return
+		}
+
+		profile := models.Profile{
+			ProfileID:       primitive.NewObjectID().Hex(),
+			Name:            strings.TrimSpace(*req.Name),
+			FavouriteGenres: user.FavouriteGenres,
+			CreatedAt:       time.Now(),
+		}
+		if req.Avatar != nil {
+			profile.Avatar = strings.TrimSpace(*req.Avatar)
+		}
+		if req.Kids != nil {
+			profile.Kids = *req.Kids
+		}
+		if req.MaxMaturity != nil {
+			limit, err := parseMaturitySetting(*req.MaxMaturity)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			profile.MaxMaturity = limit
+		}
+		if req.FavouriteGenres != nil {
+			genres, err := resolveGenres(ctx, client, *req.FavouriteGenres)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			profile.FavouriteGenres = genres
+		}
+		if profile.FavouriteGenres == nil {
+			profile.FavouriteGenres = []models.Genre{}
+		}
+
+		// The size check is part of the filter so concurrent creates cannot
+		// push the account past the limit.
+		result, err := database.OpenCollection("users", client).UpdateOne(ctx,
+			bson.M{
+				"user_id": user.UserID,
+				"$expr":   bson.M{"$lt": bson.A{bson.M{"$size": bson.M{"$ifNull": bson.A{"$profiles", bson.A{}}}}, maxProfiles}},
+			},
+			bson.M{
+				"$push": bson.M{"profiles": profile},
+				"$set":  bson.M{"updated_at": time.Now()},
+			},
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create profile"})
+			return
+		}
+		if result.MatchedCount == 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "An account can have at most " + strconv.Itoa(maxProfiles) + " profiles"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, profile)
+	}
+}
+
+// UpdateProfile edits a household profile.
+func UpdateProfile(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var req models.ProfileInput
+		if !bindProfileInput(c, &req) {
+			return
+		}
+
+		set := bson.M{}
+		unset := bson.M{}
+		if req.Kids != nil || req.MaxMaturity != nil {
+			user, ok := profileOwner(c, ctx, client)
+			if !ok {
+				return
+			}
+			if !checkParentalPIN(c, ctx, user, req.PIN) {
+				return
+			}
+		}
+		if req.Name != nil {
+			name := strings.TrimSpace(*req.Name)
+			if name == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "name cannot be empty"})
+				return
+			}
+			set["profiles.$.name"] = name
+		}
+		if req.Avatar != nil {
+			set["profiles.$.avatar"] = strings.TrimSpace(*req.Avatar)
+		}
+		if req.Kids != nil {
+			set["profiles.$.kids"] = *req.Kids
+		}
+		if req.MaxMaturity != nil {
+			limit, err := parseMaturitySetting(*req.MaxMaturity)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if limit == nil {
+				unset["profiles.$.max_maturity"] = ""
+			} else {
+				set["profiles.$.max_maturity"] = *limit
+			}
+		}
+		if req.FavouriteGenres != nil {
+			genres, err := resolveGenres(ctx, client, *req.FavouriteGenres)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			set["profiles.$.favourite_genres"] = genres
+		}
+		if len(set) == 0 && len(unset) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to update"})
+			return
+		}
+		set["updated_at"] = time.Now()
+
+		update := bson.M{"$set": set}
+		if len(unset) > 0 {
+			update["$unset"] = unset
+		}
+
+		profileId := c.Param("profile_id")
+		var user models.User
+		err := database.OpenCollection("users", client).FindOneAndUpdate(ctx,
+			bson.M{"user_id": c.GetString("userId"), "profiles.profile_id": profileId},
+			update,
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&user)
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+			return
+		}
+
+		c.JSON(http.StatusOK, user.FindProfile(profileId))
+	}
+}
+
+// DeleteProfile removes a household profile. Sessions that had it selected
+// fall back to the account itself on their next refresh, so the parental
+// PIN is required.
+func DeleteProfile(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		user, ok := profileOwner(c, ctx, client)
+		if !ok {
+			return
+		}
+		profileId := c.Param("profile_id")
+		if user.FindProfile(profileId) == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+			return
+		}
+
+		// Removing a profile can lift its limit off the session using it
+		var req struct {
+			PIN string `json:"pin"`
+		}
+		if c.Request.ContentLength != 0 {
+			_ = c.ShouldBindJSON(&req)
+		}
+		if !checkParentalPIN(c, ctx, user, req.PIN) {
+			return
+		}
+
+		result, err := database.OpenCollection("users", client).UpdateOne(ctx,
+			bson.M{"user_id": user.UserID, "profiles.profile_id": profileId},
+			bson.M{
+				"$pull": bson.M{"profiles": bson.M{"profile_id": profileId}},
+				"$set":  bson.M{"updated_at": time.Now()},
+			},
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete profile"})
+			return
+		}
+		if result.MatchedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Profile deleted", "profile_id": profileId})
+	}
+}
+
+// SelectProfile switches the current session to a household profile. It
+// issues a new token pair carrying the profile in the "pid" claim and makes
+// the new refresh token the session's current one.
+func SelectProfile(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		user, ok := profileOwner(c, ctx, client)
+		if !ok {
+			return
+		}
+		profile := user.FindProfile(c.Param("profile_id"))
+		if profile == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+			return
+		}
+
+		// Moving to a less restricted profile needs the parental PIN
+		current := userMaturityLimit(user, c.GetString("profileId"))
+		if looserThan(userMaturityLimit(user, profile.ProfileID), current) {
+			var req struct {
+				PIN string `json:"pin"`
+			}
+			if c.Request.ContentLength != 0 {
+				_ = c.ShouldBindJSON(&req)
+			}
+			if !checkParentalPIN(c, ctx, user, req.PIN) {
+				return
+			}
+		}
+
+		sessionId := c.GetString("sessionId")
+		if sessionId == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Log in again to select a profile"})
+			return
+		}
+
+		token, refreshToken, err := utils.GenerateAllTokens(user.Email, user.FirstName, user.LastName, user.Role, user.UserID, sessionId, profile.ProfileID, user.TokenVersion)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating tokens"})
+			return
+		}
+		if err := utils.ReplaceSessionToken(ctx, client, sessionId, user.UserID, refreshToken); err != nil {
+			if err == utils.ErrSessionRevoked {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Session expired or revoked"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating session"})
+			return
+		}
+
+		if utils.TokenMode(c) {
+			c.JSON(http.StatusOK, gin.H{
+				"message":       "Profile selected",
+				"profile":       profile,
+				"token":         token,
+				"refresh_token": refreshToken,
+			})
+			return
+		}
+
+		setAuthCookies(c, token, refreshToken)
+		c.JSON(http.StatusOK, gin.H{"message": "Profile selected", "profile": profile})
+	}
+}
+
+func profileOwner(c *gin.Context, ctx context.Context, client *mongo.Client) (*models.User, bool) {
+	var user models.User
+	err := database.OpenCollection("users", client).FindOne(ctx, bson.M{"user_id": c.GetString("userId")}).Decode(&user)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return nil, false
+	}
+	return &user, true
+}
+
+func bindProfileInput(c *gin.Context, req *models.ProfileInput) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return false
+	}
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,370 @@
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/database"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/models"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Supported bulk import formats
+const (
+	ImportFormatCSV   = "csv"
+	ImportFormatJSONL = "jsonl"
+)
+
+const maxImportBytes = 20 << 20
+
+// importRow is a parsed line of an import file. Err is set when the line
+// could not be turned into a movie at all.
+type importRow struct {
+	Line  int
+	Movie models.Movie
+	Err   error
+}
+
+// ImportMoviesHandler accepts a CSV or JSON Lines file, either as the raw
+// request body or as the "file" field of a multipart form, and upserts every
+// valid row on imdb_id. Pass ?dry_run=true to get the report without writing.
+func ImportMoviesHandler(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		dryRun, _ := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+
+		var body io.Reader = c.Request.Body
+		fileName := ""
+		if strings.HasPrefix(c.ContentType(), "multipart/") {
+			fileHeader, err := c.FormFile("file")
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Multipart upload must include a file field"})
+				return
+			}
+			file, err := fileHeader.Open()
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unable to read uploaded file"})
+				return
+			}
+			defer file.Close()
+			body = file
+			fileName = fileHeader.Filename
+		}
+
+		format, err := DetectImportFormat(c.Query("format"), fileName, c.ContentType())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		actorId, _ := utils.GetUserIdFromContext(c)
+
+		report, err := ImportMovies(ctx, client, body, format, dryRun, actorId)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Import failed", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// DetectImportFormat picks the import format from an explicit value, the
+// file extension or the content type, in that order.
+func DetectImportFormat(explicit, fileName, contentType string) (string, error) {
+	switch strings.ToLower(explicit) {
+	case ImportFormatCSV:
+		return ImportFormatCSV, nil
+	case ImportFormatJSONL, "ndjson", "jsonlines":
+		return ImportFormatJSONL, nil
+	case "":
+	default:
+		return "", fmt.Errorf("unsupported import format %q", explicit)
+	}
+
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		return ImportFormatCSV, nil
+	case ".jsonl", ".ndjson":
+		return ImportFormatJSONL, nil
+	}
+
+	switch contentType {
+	case "text/csv":
+		return ImportFormatCSV, nil
+	case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+		return ImportFormatJSONL, nil
+	}
+
+	return "", errors.New("unable to detect import format; pass format=csv or format=jsonl")
+}
+
+// ImportMovies validates every row of r and upserts the valid ones on
+// imdb_id. It is shared by the HTTP handler and the "import" CLI command.
+func ImportMovies(ctx context.Context, client *mongo.Client, r io.Reader, format string, dryRun bool, actorId string) (*models.ImportReport, error) {
+	var rows []importRow
+	var err error
+	switch format {
+	case ImportFormatCSV:
+		rows, err = parseCSVImport(r)
+	case ImportFormatJSONL:
+		rows, err = parseJSONLImport(r)
+	default:
+		err = fmt.Errorf("unsupported import format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ImportReport{DryRun: dryRun, Format: format, Rows: []models.ImportRowResult{}}
+	reject := func(row importRow, reason string) {
+		report.Rejected++
+		report.Rows = append(report.Rows, models.ImportRowResult{
+			Line:   row.Line,
+			ImdbID: row.Movie.ImdbID,
+			Status: models.ImportRejected,
+			Reason: reason,
+		})
+	}
+
+	// Validate everything first so existing movies can be fetched in one query.
+	var valid []importRow
+	firstSeen := make(map[string]int)
+	for _, row := range rows {
+		if row.Err != nil {
+			reject(row, row.Err.Error())
+			continue
+		}
+		if err := validate.Struct(row.Movie); err != nil {
+			reject(row, err.Error())
+			continue
+		}
+		if err := applyCertification(&row.Movie); err != nil {
+			reject(row, err.Error())
+			continue
+		}
+		if line, ok := firstSeen[row.Movie.ImdbID]; ok {
+			reject(row, fmt.Sprintf("duplicate imdb_id, first seen on line %d", line))
+			continue
+		}
+		firstSeen[row.Movie.ImdbID] = row.Line
+		valid = append(valid, row)
+	}
+
+	existing, err := findMoviesByImdbID(ctx, client, firstSeen)
+	if err != nil {
+		return nil, err
+	}
+
+	movieCollection := database.OpenCollection("movies", client)
+
+	for _, row := range valid {
+		movie := row.Movie
+		movie.ID = primitive.NilObjectID
+		movie.DeletedAt = nil
+		before, found := existing[movie.ImdbID]
+		status := models.ImportCreated
+		if found {
+			status = models.ImportUpdated
+			// Importing must not resurrect a soft-deleted movie.
+			movie.DeletedAt = before.DeletedAt
+		}
+
+		if !dryRun {
+			_, err := movieCollection.ReplaceOne(ctx,
+				bson.D{{Key: "imdb_id", Value: movie.ImdbID}},
+				movie,
+				options.Replace().SetUpsert(true),
+			)
+			if err != nil {
+				reject(row, "write failed: "+err.Error())
+				continue
+			}
+
+			if found {
+				recordMovieAudit(ctx, client, models.MovieAuditUpdate, movie.ImdbID, actorId, &before, &movie)
+			} else {
+				recordMovieAudit(ctx, client, models.MovieAuditCreate, movie.ImdbID, actorId, nil, &movie)
+			}
+		}
+
+		if found {
+			report.Updated++
+		} else {
+			report.Created++
+		}
+		report.Rows = append(report.Rows, models.ImportRowResult{
+			Line:   row.Line,
+			ImdbID: movie.ImdbID,
+			Status: status,
+		})
+	}
+
+	return report, nil
+}
+
+func findMoviesByImdbID(ctx context.Context, client *mongo.Client, ids map[string]int) (map[string]models.Movie, error) {
+	found := make(map[string]models.Movie)
+	if len(ids) == 0 {
+		return found, nil
+	}
+
+	imdbIds := make([]string, 0, len(ids))
+	for id := range ids {
+		imdbIds = append(imdbIds, id)
+	}
+
+	movieCollection := database.OpenCollection("movies", client)
+	cursor, err := movieCollection.Find(ctx, bson.D{{Key: "imdb_id", Value: bson.D{{Key: "$in", Value: imdbIds}}}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var movies []models.Movie
+	if err := cursor.All(ctx, &movies); err != nil {
+		return nil, err
+	}
+	for _, movie := range movies {
+		found[movie.ImdbID] = movie
+	}
+
+	return found, nil
+}
+
+// parseJSONLImport reads one JSON-encoded models.Movie per line. Blank lines
+// are skipped.
+func parseJSONLImport(r io.Reader) ([]importRow, error) {
+	var rows []importRow
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		row := importRow{Line: line}
+		if err := json.Unmarshal([]byte(text), &row.Movie); err != nil {
+			row.Err = fmt.Errorf("invalid JSON: %v", err)
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// parseCSVImport reads a CSV file whose header names the movie's JSON fields:
+// imdb_id, title, poster_path, youtube_id, genre, admin_review, ranking_name,
+// ranking_value and the optional certification. Genres are written as
+// "id:name" pairs separated by "|", e.g. "1:Comedy|3:Drama".
+func parseCSVImport(r io.Reader) ([]importRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read CSV header: %v", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"imdb_id", "title", "poster_path", "youtube_id", "genre", "ranking_name", "ranking_value"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("CSV header is missing column %q", required)
+		}
+	}
+
+	var rows []importRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rows = append(rows, importRow{Line: parseErr.StartLine, Err: err})
+				continue
+			}
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row := importRow{Line: line}
+		row.Movie = models.Movie{
+			ImdbID:        field("imdb_id"),
+			Title:         field("title"),
+			PosterPath:    field("poster_path"),
+			YouTubeID:     field("youtube_id"),
+			AdminReview:   field("admin_review"),
+			Certification: field("certification"),
+			Ranking:       models.Ranking{RankingName: field("ranking_name")},
+		}
+
+		if row.Movie.Genre, err = parseCSVGenres(field("genre")); err != nil {
+			row.Err = err
+		} else if row.Movie.Ranking.RankingValue, err = strconv.Atoi(field("ranking_value")); err != nil {
+			row.Err = errors.New("ranking_value must be an integer")
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func parseCSVGenres(raw string) ([]models.Genre, error) {
+	var genres []models.Genre
+	for _, part := range strings.Split(raw, "|") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		idStr, name, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("genre %q must be written as id:name", part)
+		}
+		id, err := strconv.Atoi(strings.TrimSpace(idStr))
+		if err != nil {
+			return nil, fmt.Errorf("genre %q has a non-numeric id", part)
+		}
+		genres = append(genres, models.Genre{GenreID: id, GenreName: strings.TrimSpace(name)})
+	}
+	return genres, nil
+}
@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/jobs"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GetJob lets clients poll the status of a background job.
+func GetJob(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		job, err := jobs.Get(ctx, client, c.Param("job_id"))
+		if err != nil {
+			if err == jobs.ErrJobNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch job"})
+			return
+		}
+
+		c.JSON(http.StatusOK, job)
+	}
+}
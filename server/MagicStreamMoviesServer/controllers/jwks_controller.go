@@ -0,0 +1,17 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// GetJWKS publishes the public keys that MagicStream tokens are signed with,
+// so other services can verify them without sharing a secret.
+func GetJWKS() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, gin.H{"keys": utils.JWKS()})
+	}
+}
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/models"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/throttle"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Login attempts are limited per email and per client IP. Unknown emails are
+// counted exactly like real ones and get the same responses, so neither the
+// throttling nor the lockout reveals whether an account exists.
+
+// loginThrottled writes a 429 and returns true if either the email or the
+// client IP has to wait before trying again.
+func loginThrottled(c *gin.Context, ctx context.Context, email string) bool {
+	store := throttle.Default()
+
+	var wait time.Duration
+	for _, check := range []struct {
+		key    string
+		policy throttle.Policy
+	}{
+		{throttle.EmailKey(email), throttle.EmailPolicy()},
+		{throttle.IPKey(c.ClientIP()), throttle.IPPolicy()},
+	} {
+		status, err := store.Status(ctx, check.key, check.policy)
+		if err != nil {
+			// Fail open: a throttle outage should not stop every login
+			log.Println("Login throttle check failed:", err)
+			continue
+		}
+		wait = max(wait, status.RetryAfter)
+	}
+
+	if wait <= 0 {
+		return false
+	}
+
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	c.JSON(http.StatusTooManyRequests, models.APIResponse{
+		Status:    "error",
+		Error:     true,
+		Message:   "Too many login attempts. Please try again later.",
+		Timestamp: time.Now(),
+	})
+	return true
+}
+
+// recordLoginFailure counts a failed login against the email and the client
+// IP, and audits any lockout it starts.
+func recordLoginFailure(c *gin.Context, ctx context.Context, client *mongo.Client, email string) {
+	store := throttle.Default()
+	ip := c.ClientIP()
+
+	for _, failure := range []struct {
+		key    string
+		policy throttle.Policy
+	}{
+		{throttle.EmailKey(email), throttle.EmailPolicy()},
+		{throttle.IPKey(ip), throttle.IPPolicy()},
+	} {
+		status, err := store.RecordFailure(ctx, failure.key, failure.policy)
+		if err != nil {
+			log.Println("Failed to record login failure:", err)
+			continue
+		}
+		if status.JustLocked {
+			until := status.LockedUntil
+			log.Println("Login locked for", failure.key, "until", until)
+			recordAuthAudit(ctx, client, models.AuthAudit{
+				Event:     models.AuthAuditLockout,
+				Key:       failure.key,
+				Email:     strings.ToLower(strings.TrimSpace(email)),
+				IPAddress: ip,
+				Until:     &until,
+			})
+		}
+	}
+}
+
+// resetLoginFailures clears the email's counter after a successful password
+// check. The IP counter is left to expire so an attacker cannot reset it by
+// logging in to their own account between guesses.
+func resetLoginFailures(ctx context.Context, email string) {
+	if err := throttle.Default().Reset(ctx, throttle.EmailKey(email)); err != nil {
+		log.Println("Failed to reset login failures:", err)
+	}
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+// burnPasswordCheck spends as long as a real password comparison so a
+// missing account cannot be told apart by response time.
+func burnPasswordCheck(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = utils.HashPassword("not-a-real-password")
+	})
+	utils.VerifyPassword(dummyHash, password)
+}
+
+// UnlockLogin lets an admin lift a lockout on an email, an IP, or both.
+func UnlockLogin(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var req struct {
+			Email string `json:"email"`
+			IP    string `json:"ip"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || (req.Email == "" && req.IP == "") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "email or ip is required"})
+			return
+		}
+
+		var keys []string
+		if req.Email != "" {
+			keys = append(keys, throttle.EmailKey(req.Email))
+		}
+		if req.IP != "" {
+			keys = append(keys, throttle.IPKey(req.IP))
+		}
+
+		for _, key := range keys {
+			if err := throttle.Default().Reset(ctx, key); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock"})
+				return
+			}
+			recordAuthAudit(ctx, client, models.AuthAudit{
+				Event:     models.AuthAuditUnlock,
+				Key:       key,
+				Email:     strings.ToLower(strings.TrimSpace(req.Email)),
+				IPAddress: req.IP,
+				ActorID:   c.GetString("userId"),
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Unlocked", "keys": keys})
+	}
+}
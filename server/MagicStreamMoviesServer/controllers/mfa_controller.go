@@ -0,0 +1,517 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/database"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/models"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	recoveryCodeCount = 10
+
+	// After maxMFAFailures wrong codes in a row, second factor checks are
+	// refused for mfaLockout.
+	maxMFAFailures = 5
+	mfaLockout     = 15 * time.Minute
+)
+
+var (
+	errMFAInvalidCode = errors.New("invalid two-factor code")
+	errMFALocked      = errors.New("too many invalid two-factor codes; try again later")
+	errMFANotPending  = errors.New("no two-factor enrollment in progress")
+)
+
+type mfaCodeRequest struct {
+	MFAToken     string `json:"mfa_token"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+	Password     string `json:"password"`
+}
+
+// =========================
+// LOGIN SECOND STEP
+// =========================
+
+// respondMFAChallenge ends the password step of a login by handing out a
+// token for the second step instead of a session.
+func respondMFAChallenge(c *gin.Context, user *models.User, use, message string) {
+	mfaToken, err := utils.GenerateMFAToken(user.UserID, user.TokenVersion, use)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Status:    "error",
+			Error:     true,
+			Message:   "Failed to generate tokens",
+			Content:   err.Error(),
+			Timestamp: time.Now(),
+		})
+		return
+	}
+
+	content := gin.H{"mfaToken": mfaToken}
+	if use == utils.TokenUseMFAEnroll {
+		content["mfaEnrollmentRequired"] = true
+	} else {
+		content["mfaRequired"] = true
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Status:    "success",
+		Error:     false,
+		Message:   message,
+		Content:   content,
+		Timestamp: time.Now(),
+	})
+}
+
+// LoginMFA completes a login with a TOTP code or a recovery code.
+func LoginMFA(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var req mfaCodeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			mfaFail(c, http.StatusBadRequest, "Invalid input data")
+			return
+		}
+
+		user, ok := userFromMFAToken(c, ctx, client, req.MFAToken, utils.TokenUseMFA)
+		if !ok {
+			return
+		}
+
+		if err := verifySecondFactor(ctx, client, user, req.Code, req.RecoveryCode); err != nil {
+			mfaError(c, err)
+			return
+		}
+
+		userContent, ok := completeLogin(c, ctx, client, user)
+		if !ok {
+			return
+		}
+
+		c.JSON(http.StatusOK, models.APIResponse{
+			Status:    "success",
+			Error:     false,
+			Message:   "Login successful",
+			Content:   userContent,
+			Timestamp: time.Now(),
+		})
+	}
+}
+
+// LoginMFAEnroll starts the enrollment a role requires, using the token
+// from the password step in place of a session.
+func LoginMFAEnroll(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var req mfaCodeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			mfaFail(c, http.StatusBadRequest, "Invalid input data")
+			return
+		}
+
+		user, ok := userFromMFAToken(c, ctx, client, req.MFAToken, utils.TokenUseMFAEnroll)
+		if !ok {
+			return
+		}
+
+		respondEnrollment(c, ctx, client, user)
+	}
+}
+
+// LoginMFAEnrollConfirm finishes a required enrollment and logs the user
+// in. The recovery codes are only ever shown in this response.
+func LoginMFAEnrollConfirm(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var req mfaCodeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			mfaFail(c, http.StatusBadRequest, "Invalid input data")
+			return
+		}
+
+		user, ok := userFromMFAToken(c, ctx, client, req.MFAToken, utils.TokenUseMFAEnroll)
+		if !ok {
+			return
+		}
+
+		codes, err := confirmMFAEnrollment(ctx, client, user, req.Code)
+		if err != nil {
+			mfaError(c, err)
+			return
+		}
+
+		userContent, ok := completeLogin(c, ctx, client, user)
+		if !ok {
+			return
+		}
+
+		c.JSON(http.StatusOK, models.APIResponse{
+			Status:    "success",
+			Error:     false,
+			Message:   "Two-factor authentication enabled; login successful",
+			Content:   gin.H{"user": userContent, "recoveryCodes": codes},
+			Timestamp: time.Now(),
+		})
+	}
+}
+
+// =========================
+// SELF-SERVICE
+// =========================
+
+// EnrollMFA starts two-factor enrollment for the logged-in user. It returns
+// the secret and an otpauth:// URI for the authenticator app; nothing changes
+// until ConfirmMFA receives a valid code.
+func EnrollMFA(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		user, ok := currentUser(c, ctx, client)
+		if !ok {
+			return
+		}
+		if user.MFAEnabled {
+			mfaFail(c, http.StatusConflict, "Two-factor authentication is already enabled")
+			return
+		}
+
+		respondEnrollment(c, ctx, client, user)
+	}
+}
+
+// ConfirmMFA enables two-factor authentication once the user proves their
+// app produces valid codes, and returns the recovery codes.
+func ConfirmMFA(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var req mfaCodeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			mfaFail(c, http.StatusBadRequest, "Invalid input data")
+			return
+		}
+
+		user, ok := currentUser(c, ctx, client)
+		if !ok {
+			return
+		}
+
+		codes, err := confirmMFAEnrollment(ctx, client, user, req.Code)
+		if err != nil {
+			mfaError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, models.APIResponse{
+			Status:    "success",
+			Error:     false,
+			Message:   "Two-factor authentication enabled",
+			Content:   gin.H{"recoveryCodes": codes},
+			Timestamp: time.Now(),
+		})
+	}
+}
+
+// DisableMFA turns two-factor authentication off. It needs the password and
+// a current code or recovery code, and is refused for roles that require it.
+func DisableMFA(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var req mfaCodeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			mfaFail(c, http.StatusBadRequest, "Invalid input data")
+			return
+		}
+
+		user, ok := currentUser(c, ctx, client)
+		if !ok {
+			return
+		}
+		if !user.MFAEnabled {
+			mfaFail(c, http.StatusConflict, "Two-factor authentication is not enabled")
+			return
+		}
+		if utils.MFARequiredForRole(user.Role) {
+			mfaFail(c, http.StatusForbidden, "Two-factor authentication is required for your role")
+			return
+		}
+		// A stolen access token must not become a way to guess the password
+		if loginThrottled(c, ctx, user.Email) {
+			return
+		}
+		if ok, _ := utils.VerifyPassword(user.Password, req.Password); !ok {
+			recordLoginFailure(c, ctx, client, user.Email)
+			mfaFail(c, http.StatusUnauthorized, "Invalid password")
+			return
+		}
+		resetLoginFailures(ctx, user.Email)
+		if err := verifySecondFactor(ctx, client, user, req.Code, req.RecoveryCode); err != nil {
+			mfaError(c, err)
+			return
+		}
+
+		_, err := database.OpenCollection("users", client).UpdateOne(ctx,
+			bson.M{"user_id": user.UserID},
+			bson.M{
+				"$set": bson.M{"mfa_enabled": false, "updated_at": time.Now()},
+				"$unset": bson.M{
+					"mfa_secret": "", "mfa_pending_secret": "", "mfa_recovery_codes": "",
+					"mfa_last_step": "", "mfa_failures": "", "mfa_locked_until": "",
+				},
+			},
+		)
+		if err != nil {
+			mfaFail(c, http.StatusInternalServerError, "Failed to disable two-factor authentication")
+			return
+		}
+
+		c.JSON(http.StatusOK, models.APIResponse{
+			Status:    "success",
+			Error:     false,
+			Message:   "Two-factor authentication disabled",
+			Timestamp: time.Now(),
+		})
+	}
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after checking a
+// current TOTP code.
+func RegenerateRecoveryCodes(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var req mfaCodeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			mfaFail(c, http.StatusBadRequest, "Invalid input data")
+			return
+		}
+
+		user, ok := currentUser(c, ctx, client)
+		if !ok {
+			return
+		}
+		if !user.MFAEnabled {
+			mfaFail(c, http.StatusConflict, "Two-factor authentication is not enabled")
+			return
+		}
+		if err := verifySecondFactor(ctx, client, user, req.Code, ""); err != nil {
+			mfaError(c, err)
+			return
+		}
+
+		codes, hashes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+		if err == nil {
+			_, err = database.OpenCollection("users", client).UpdateOne(ctx,
+				bson.M{"user_id": user.UserID},
+				bson.M{"$set": bson.M{"mfa_recovery_codes": hashes, "updated_at": time.Now()}},
+			)
+		}
+		if err != nil {
+			mfaFail(c, http.StatusInternalServerError, "Failed to generate recovery codes")
+			return
+		}
+
+		c.JSON(http.StatusOK, models.APIResponse{
+			Status:    "success",
+			Error:     false,
+			Message:   "Recovery codes regenerated",
+			Content:   gin.H{"recoveryCodes": codes},
+			Timestamp: time.Now(),
+		})
+	}
+}
+
+// =========================
+// HELPERS
+// =========================
+
+func currentUser(c *gin.Context, ctx context.Context, client *mongo.Client) (*models.User, bool) {
+	var user models.User
+	err := database.OpenCollection("users", client).FindOne(ctx, bson.M{"user_id": c.GetString("userId")}).Decode(&user)
+	if err != nil {
+		mfaFail(c, http.StatusUnauthorized, "User not found")
+		return nil, false
+	}
+	return &user, true
+}
+
+func userFromMFAToken(c *gin.Context, ctx context.Context, client *mongo.Client, mfaToken, use string) (*models.User, bool) {
+	claims, err := utils.ValidateMFAToken(mfaToken, use)
+	if err != nil {
+		mfaFail(c, http.StatusUnauthorized, "Invalid or expired MFA token; log in again")
+		return nil, false
+	}
+
+	var user models.User
+	err = database.OpenCollection("users", client).FindOne(ctx, bson.M{"user_id": claims.UserId}).Decode(&user)
+	if err != nil {
+		mfaFail(c, http.StatusUnauthorized, "Invalid or expired MFA token; log in again")
+		return nil, false
+	}
+	return &user, true
+}
+
+func respondEnrollment(c *gin.Context, ctx context.Context, client *mongo.Client, user *models.User) {
+	secret, err := utils.GenerateTOTPSecret()
+	if err == nil {
+		_, err = database.OpenCollection("users", client).UpdateOne(ctx,
+			bson.M{"user_id": user.UserID},
+			bson.M{"$set": bson.M{"mfa_pending_secret": secret, "updated_at": time.Now()}},
+		)
+	}
+	if err != nil {
+		mfaFail(c, http.StatusInternalServerError, "Failed to start two-factor enrollment")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Status:  "success",
+		Error:   false,
+		Message: "Scan the provisioning URI with an authenticator app, then confirm with a code",
+		Content: gin.H{
+			"secret":          secret,
+			"provisioningUri": utils.TOTPProvisioningURI(secret, user.Email),
+		},
+		Timestamp: time.Now(),
+	})
+}
+
+// confirmMFAEnrollment checks code against the pending secret and, if it
+// matches, makes it the user's secret and issues fresh recovery codes.
+func confirmMFAEnrollment(ctx context.Context, client *mongo.Client, user *models.User, code string) ([]string, error) {
+	if user.MFAPending == "" {
+		return nil, errMFANotPending
+	}
+
+	step, ok := utils.VerifyTOTP(user.MFAPending, code, time.Now())
+	if !ok {
+		return nil, errMFAInvalidCode
+	}
+
+	codes, hashes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := database.OpenCollection("users", client).UpdateOne(ctx,
+		bson.M{"user_id": user.UserID, "mfa_pending_secret": user.MFAPending},
+		bson.M{
+			"$set": bson.M{
+				"mfa_enabled":        true,
+				"mfa_secret":         user.MFAPending,
+				"mfa_recovery_codes": hashes,
+				"mfa_last_step":      step,
+				"updated_at":         time.Now(),
+			},
+			"$unset": bson.M{"mfa_pending_secret": "", "mfa_failures": "", "mfa_locked_until": ""},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		return nil, errMFANotPending
+	}
+
+	user.MFAEnabled = true
+	return codes, nil
+}
+
+// verifySecondFactor accepts either a TOTP code or an unused recovery code.
+// Both are consumed atomically: a TOTP step can only be used once and a
+// recovery code is removed when used, so neither can be replayed.
+func verifySecondFactor(ctx context.Context, client *mongo.Client, user *models.User, code, recoveryCode string) error {
+	if !user.MFAEnabled || user.MFASecret == "" {
+		return errMFAInvalidCode
+	}
+	if user.MFALockedUntil != nil && user.MFALockedUntil.After(time.Now()) {
+		return errMFALocked
+	}
+
+	userCollection := database.OpenCollection("users", client)
+	var result *mongo.UpdateResult
+	var err error
+
+	switch {
+	case code != "":
+		step, ok := utils.VerifyTOTP(user.MFASecret, code, time.Now())
+		if ok {
+			result, err = userCollection.UpdateOne(ctx,
+				bson.M{"user_id": user.UserID, "mfa_last_step": bson.M{"$not": bson.M{"$gte": step}}},
+				bson.M{"$set": bson.M{"mfa_last_step": step}, "$unset": bson.M{"mfa_failures": "", "mfa_locked_until": ""}},
+			)
+		}
+	case recoveryCode != "":
+		hash := utils.HashRecoveryCode(recoveryCode)
+		result, err = userCollection.UpdateOne(ctx,
+			bson.M{"user_id": user.UserID, "mfa_recovery_codes": hash},
+			bson.M{"$pull": bson.M{"mfa_recovery_codes": hash}, "$unset": bson.M{"mfa_failures": "", "mfa_locked_until": ""}},
+		)
+	}
+	if err != nil {
+		return err
+	}
+	if result != nil && result.MatchedCount == 1 {
+		return nil
+	}
+
+	// Count the failure and lock further attempts once there are too many.
+	var updated models.User
+	err = userCollection.FindOneAndUpdate(ctx,
+		bson.M{"user_id": user.UserID},
+		bson.M{"$inc": bson.M{"mfa_failures": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err == nil && updated.MFAFailures >= maxMFAFailures {
+		_, err = userCollection.UpdateOne(ctx,
+			bson.M{"user_id": user.UserID},
+			bson.M{"$set": bson.M{"mfa_locked_until": time.Now().Add(mfaLockout), "mfa_failures": 0}},
+		)
+	}
+	if err != nil {
+		return err
+	}
+	return errMFAInvalidCode
+}
+
+func mfaError(c *gin.Context, err error) {
+	switch err {
+	case errMFAInvalidCode:
+		mfaFail(c, http.StatusUnauthorized, err.Error())
+	case errMFALocked:
+		mfaFail(c, http.StatusTooManyRequests, err.Error())
+	case errMFANotPending:
+		mfaFail(c, http.StatusConflict, err.Error())
+	default:
+		mfaFail(c, http.StatusInternalServerError, "Failed to verify two-factor code")
+	}
+}
+
+func mfaFail(c *gin.Context, status int, message string) {
+	c.JSON(status, models.APIResponse{
+		Status:    "error",
+		Error:     true,
+		Message:   message,
+		Timestamp: time.Now(),
+	})
+}
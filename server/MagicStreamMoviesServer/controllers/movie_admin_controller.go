@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/database"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/models"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// UpdateMovie handles PUT (full replacement) and PATCH (only the fields
+// present in the body) on /movie/:imdb_id.
+func UpdateMovie(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		movieId := c.Param("imdb_id")
+		if movieId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Movie Id required"})
+			return
+		}
+
+		movieCollection := database.OpenCollection("movies", client)
+
+		var before models.Movie
+		err := movieCollection.FindOne(ctx, bson.D{{Key: "imdb_id", Value: movieId}, notDeleted}).Decode(&before)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch movie"})
+			return
+		}
+
+		var movie models.Movie
+		if c.Request.Method == http.MethodPatch {
+			movie = before
+			// Copy the slice so decoding into it cannot rewrite the audit snapshot.
+			movie.Genre = append([]models.Genre(nil), before.Genre...)
+			// Likewise for the pointer; applyCertification derives it again anyway.
+			movie.MaturityAge = nil
+		}
+		if err := c.ShouldBindJSON(&movie); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		if movie.ImdbID != "" && movie.ImdbID != movieId {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "imdb_id cannot be changed"})
+			return
+		}
+		movie.ID = before.ID
+		movie.ImdbID = movieId
+		movie.DeletedAt = nil
+		// Ranking state belongs to the review job, not the request body
+		movie.RankingStatus = before.RankingStatus
+		movie.RankingJobID = before.RankingJobID
+
+		if err := validate.Struct(movie); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+		if err := applyCertification(&movie); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		result, err := movieCollection.ReplaceOne(ctx, bson.D{{Key: "_id", Value: before.ID}, notDeleted}, movie)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update movie"})
+			return
+		}
+		if result.MatchedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+			return
+		}
+
+		actorId, _ := utils.GetUserIdFromContext(c)
+		recordMovieAudit(ctx, client, models.MovieAuditUpdate, movieId, actorId, &before, &movie)
+
+		c.JSON(http.StatusOK, movie)
+	}
+}
+
+// DeleteMovie soft deletes a movie by setting deleted_at. Deleted movies are
+// hidden from every read endpoint until restored.
+func DeleteMovie(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		movieId := c.Param("imdb_id")
+		if movieId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Movie Id required"})
+			return
+		}
+
+		movieCollection := database.OpenCollection("movies", client)
+
+		deletedAt := time.Now()
+		var before models.Movie
+		err := movieCollection.FindOneAndUpdate(ctx,
+			bson.D{{Key: "imdb_id", Value: movieId}, notDeleted},
+			bson.M{"$set": bson.M{"deleted_at": deletedAt}},
+		).Decode(&before)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete movie"})
+			return
+		}
+
+		after := before
+		after.DeletedAt = &deletedAt
+
+		actorId, _ := utils.GetUserIdFromContext(c)
+		recordMovieAudit(ctx, client, models.MovieAuditDelete, movieId, actorId, &before, &after)
+
+		c.JSON(http.StatusOK, gin.H{"message": "Movie deleted", "imdb_id": movieId, "deleted_at": deletedAt})
+	}
+}
+
+// RestoreMovie clears deleted_at on a soft-deleted movie.
+func RestoreMovie(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		movieId := c.Param("imdb_id")
+		if movieId == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Movie Id required"})
+			return
+		}
+
+		movieCollection := database.OpenCollection("movies", client)
+
+		var before models.Movie
+		err := movieCollection.FindOneAndUpdate(ctx,
+			bson.D{{Key: "imdb_id", Value: movieId}, {Key: "deleted_at", Value: bson.D{{Key: "$exists", Value: true}}}},
+			bson.M{"$unset": bson.M{"deleted_at": ""}},
+		).Decode(&before)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Deleted movie not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore movie"})
+			return
+		}
+
+		after := before
+		after.DeletedAt = nil
+
+		actorId, _ := utils.GetUserIdFromContext(c)
+		recordMovieAudit(ctx, client, models.MovieAuditRestore, movieId, actorId, &before, &after)
+
+		c.JSON(http.StatusOK, after)
+	}
+}
@@ -2,22 +2,22 @@ package controllers
 
 import (
 	"context"
-	"errors"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
-	"strings"
 	"time"
 
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/classifier"
 	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/database"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/jobs"
 	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/models"
 	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"github.com/joho/godotenv"
-	"github.com/tmc/langchaingo/llms/openai"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -29,23 +29,75 @@ func GetMovies(client *mongo.Client) gin.HandlerFunc {
 		ctx, cancel := context.WithTimeout(c, 100*time.Second)
 		defer cancel()
 
+		page, pageSize, err := parsePagination(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		filter, err := buildMovieFilter(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		sort, err := parseMovieSort(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		limit, err := maturityLimit(ctx, client, c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load parental controls."})
+			return
+		}
+		filter = append(filter, maturityScope(limit)...)
+
 		var movieCollection = database.OpenCollection("movies", client)
 
-		cursor, err := movieCollection.Find(ctx, bson.D{})
+		total, err := movieCollection.CountDocuments(ctx, filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count movies."})
+			return
+		}
+
+		findOptions := options.Find()
+		findOptions.SetSort(sort)
+		findOptions.SetSkip((page - 1) * pageSize)
+		findOptions.SetLimit(pageSize)
 
+		cursor, err := movieCollection.Find(ctx, filter, findOptions)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch movies."})
+			return
 		}
 		defer cursor.Close(ctx)
 
-		var movies []models.Movie
+		movies := []models.Movie{}
 
 		if err = cursor.All(ctx, &movies); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode movies."})
 			return
 		}
 
-		c.JSON(http.StatusOK, movies)
+		totalPages := (total + pageSize - 1) / pageSize
+
+		response := models.MoviePage{
+			Movies:     movies,
+			Total:      total,
+			Page:       page,
+			PageSize:   pageSize,
+			TotalPages: totalPages,
+		}
+		if page < totalPages {
+			response.Next = pageLink(c, page+1)
+		}
+		if page > 1 {
+			response.Prev = pageLink(c, min(page-1, max(totalPages, 1)))
+		}
+
+		c.JSON(http.StatusOK, response)
 
 	}
 }
@@ -66,13 +118,23 @@ func GetMovie(client *mongo.Client) gin.HandlerFunc {
 
 		var movie models.Movie
 
-		err := movieCollection.FindOne(ctx, bson.D{{Key: "imdb_id", Value: movieID}}).Decode(&movie)
+		err := movieCollection.FindOne(ctx, bson.D{{Key: "imdb_id", Value: movieID}, notDeleted}).Decode(&movie)
 
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
 			return
 		}
 
+		limit, err := maturityLimit(ctx, client, c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load parental controls"})
+			return
+		}
+		if !movieAllowed(&movie, limit) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "This title is not available on this profile"})
+			return
+		}
+
 		c.JSON(http.StatusOK, movie)
 
 	}
@@ -93,37 +155,78 @@ func AddMovie(client *mongo.Client) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
 			return
 		}
+		if err := applyCertification(&movie); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
 		var movieCollection = database.OpenCollection("movies", client)
 
+		var existing models.Movie
+		err := movieCollection.FindOne(ctx, bson.D{{Key: "imdb_id", Value: movie.ImdbID}}).Decode(&existing)
+		if err == nil {
+			movieConflict(c, existing)
+			return
+		}
+		if err != mongo.ErrNoDocuments {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing movie"})
+			return
+		}
+
+		movie.ID = primitive.NilObjectID
+		movie.DeletedAt = nil
+		movie.RankingStatus = ""
+		movie.RankingJobID = ""
+
 		result, err := movieCollection.InsertOne(ctx, movie)
 
 		if err != nil {
+			// Lost a race with a concurrent insert; the unique index caught it.
+			if mongo.IsDuplicateKeyError(err) {
+				if err := movieCollection.FindOne(ctx, bson.D{{Key: "imdb_id", Value: movie.ImdbID}}).Decode(&existing); err == nil {
+					movieConflict(c, existing)
+					return
+				}
+				c.JSON(http.StatusConflict, gin.H{"error": "Movie already exists", "location": movieLocation(movie.ImdbID)})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add movie"})
 			return
 		}
 
-		c.JSON(http.StatusCreated, result)
+		if id, ok := result.InsertedID.(primitive.ObjectID); ok {
+			movie.ID = id
+		}
+
+		actorId, _ := utils.GetUserIdFromContext(c)
+		recordMovieAudit(ctx, client, models.MovieAuditCreate, movie.ImdbID, actorId, nil, &movie)
+
+		c.Header("Location", movieLocation(movie.ImdbID))
+		c.JSON(http.StatusCreated, movie)
 
 	}
 }
-func AdminReviewUpdate(client *mongo.Client) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Get role from context
-		role, err := utils.GetRoleFromContext(c)
-		if err != nil {
-			log.Println("Role not found in context:", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Role not found in context"})
-			return
-		}
 
-		log.Println("User role:", role)
+// movieLocation is the canonical URL of a movie resource.
+func movieLocation(imdbId string) string {
+	return "/movie/" + url.PathEscape(imdbId)
+}
 
-		if role != "ADMIN" {
-			log.Println("Unauthorized role:", role)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User must be part of the ADMIN role"})
-			return
-		}
+// movieConflict responds 409 pointing at the movie that already owns the
+// imdb_id. Soft-deleted movies are flagged so the caller knows to restore
+// rather than re-add them.
+func movieConflict(c *gin.Context, existing models.Movie) {
+	location := movieLocation(existing.ImdbID)
+	c.Header("Location", location)
+	c.JSON(http.StatusConflict, gin.H{
+		"error":    "Movie already exists",
+		"imdb_id":  existing.ImdbID,
+		"location": location,
+		"deleted":  existing.DeletedAt != nil,
+	})
+}
 
+func AdminReviewUpdate(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
 		// Get movie ID from URL
 		movieId := c.Param("imdb_id")
 		if movieId == "" {
@@ -144,120 +247,127 @@ func AdminReviewUpdate(client *mongo.Client) gin.HandlerFunc {
 
 		log.Println("Received admin review for movie:", movieId, "Review:", req.AdminReview)
 
-		// Get sentiment & ranking
-		sentiment, rankVal, err := GetReviewRanking(req.AdminReview, client, c)
-		if err != nil {
-			log.Println("GetReviewRanking error:", err, "Input:", req.AdminReview)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error getting review ranking"})
-			return
-		}
+		var ctx, cancel = context.WithTimeout(c, 100*time.Second)
+		defer cancel()
 
-		log.Println("Sentiment:", sentiment, "Ranking value:", rankVal)
+		actorId, _ := utils.GetUserIdFromContext(c)
+
+		// Classification runs in the background; the review is saved now.
+		// The job id is written onto the movie before the job exists, so a
+		// worker can never claim it while the movie still points elsewhere.
+		jobId := primitive.NewObjectID()
 
 		// Prepare MongoDB update
-		filter := bson.D{{Key: "imdb_id", Value: movieId}}
+		filter := bson.D{{Key: "imdb_id", Value: movieId}, notDeleted}
 		update := bson.M{
 			"$set": bson.M{
-				"admin_review": req.AdminReview,
-				"ranking": bson.M{
-					"ranking_value": rankVal,
-					"ranking_name":  sentiment,
-				},
+				"admin_review":   req.AdminReview,
+				"ranking_status": models.RankingStatusPending,
+				"ranking_job_id": jobId.Hex(),
 			},
 		}
 
-		var ctx, cancel = context.WithTimeout(c, 100*time.Second)
-		defer cancel()
-
 		movieCollection := database.OpenCollection("movies", client)
-		if movieCollection == nil {
-			log.Println("Movie collection is nil")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Movie collection not found"})
-			return
-		}
 
 		log.Println("Updating movie in MongoDB:", movieId, "with update:", update)
 
-		result, err := movieCollection.UpdateOne(ctx, filter, update)
+		var before models.Movie
+		err := movieCollection.FindOneAndUpdate(ctx, filter, update).Decode(&before)
 		if err != nil {
-			log.Println("MongoDB UpdateOne error:", err)
+			if err == mongo.ErrNoDocuments {
+				log.Println("No movie matched for update with ID:", movieId)
+				c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+				return
+			}
+			log.Println("MongoDB FindOneAndUpdate error:", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating movie"})
 			return
 		}
 
-		if result.MatchedCount == 0 {
-			log.Println("No movie matched for update with ID:", movieId)
-			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+		job, err := jobs.EnqueueWithID(ctx, client, jobId, ClassifyReviewJobType, map[string]interface{}{
+			"imdb_id":      movieId,
+			"admin_review": req.AdminReview,
+			"actor_id":     actorId,
+		}, time.Now())
+		if err != nil {
+			log.Println("Failed to enqueue review classification:", err)
+			// Nothing will rank the review; don't leave it pending forever
+			_, _ = movieCollection.UpdateOne(ctx,
+				bson.D{{Key: "imdb_id", Value: movieId}, {Key: "ranking_job_id", Value: jobId.Hex()}},
+				bson.M{"$set": bson.M{"ranking_status": models.RankingStatusFailed}},
+			)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error scheduling review ranking"})
 			return
 		}
 
-		log.Println("Movie updated successfully. MatchedCount:", result.MatchedCount, "ModifiedCount:", result.ModifiedCount)
+		log.Println("Movie review saved, ranking pending:", movieId, "job:", job.ID.Hex())
 
-		// Respond with updated data
-		c.JSON(http.StatusOK, gin.H{
-			"ranking_name": sentiment,
-			"admin_review": req.AdminReview,
+		after := before
+		after.AdminReview = req.AdminReview
+		after.RankingStatus = models.RankingStatusPending
+		after.RankingJobID = job.ID.Hex()
+
+		recordMovieAudit(ctx, client, models.MovieAuditReview, movieId, actorId, &before, &after)
+
+		// Respond with the saved review and where to poll for the ranking
+		c.JSON(http.StatusAccepted, gin.H{
+			"admin_review":   req.AdminReview,
+			"ranking_status": models.RankingStatusPending,
+			"job_id":         job.ID.Hex(),
+			"status_url":     "/jobs/" + job.ID.Hex(),
 		})
 	}
 }
 
-func GetReviewRanking(admin_review string, client *mongo.Client, c *gin.Context) (string, int, error) {
+func GetReviewRanking(admin_review string, client *mongo.Client, c context.Context) (string, int, error) {
 	rankings, err := GetRankings(client, c)
 
 	if err != nil {
 		return "", 0, err
 	}
 
-	sentimentDelimited := ""
-
-	for _, ranking := range rankings {
-		if ranking.RankingValue != 999 {
-			sentimentDelimited = sentimentDelimited + ranking.RankingName + ","
-		}
-	}
-
-	sentimentDelimited = strings.Trim(sentimentDelimited, ",")
-
-	err = godotenv.Load(".env")
+	ranking, err := classifyReview(c, admin_review, rankableRankings(rankings))
 
 	if err != nil {
-		log.Println("Warning: .env file not found")
+		return "", 0, err
 	}
+	return ranking.RankingName, ranking.RankingValue, nil
 
-	OpenAiApiKey := os.Getenv("OPENAI_API_KEY")
+}
 
-	if OpenAiApiKey == "" {
-		return "", 0, errors.New("could not read OPENAI_API_KEY")
+// rankableRankings drops the 999 "not ranked" placeholder, which is never
+// offered to the classifier.
+func rankableRankings(rankings []models.Ranking) []models.Ranking {
+	var candidates []models.Ranking
+
+	for _, ranking := range rankings {
+		if ranking.RankingValue != 999 {
+			candidates = append(candidates, ranking)
+		}
 	}
+	return candidates
+}
 
-	llm, err := openai.New(openai.WithToken(OpenAiApiKey))
+// classifyReview runs the configured classifier and maps its answer onto
+// one of candidates.
+func classifyReview(ctx context.Context, review string, candidates []models.Ranking) (models.Ranking, error) {
+	sentimentClassifier, err := classifier.Default()
 
 	if err != nil {
-		return "", 0, err
+		return models.Ranking{}, err
 	}
 
-	base_prompt_template := os.Getenv("BASE_PROMPT_TEMPLATE")
-
-	base_prompt := strings.Replace(base_prompt_template, "{rankings}", sentimentDelimited, 1)
-
-	response, err := llm.Call(c, base_prompt+admin_review)
+	response, err := sentimentClassifier.Classify(ctx, review, candidates)
 
 	if err != nil {
-		return "", 0, err
+		return models.Ranking{}, err
 	}
-	rankVal := 0
-
-	for _, ranking := range rankings {
-		if ranking.RankingName == response {
-			rankVal = ranking.RankingValue
-			break
-		}
-	}
-	return response, rankVal, nil
 
+	// Classifiers already normalize, but never trust a name we cannot map
+	return classifier.MatchRanking(response, candidates)
 }
 
-func GetRankings(client *mongo.Client, c *gin.Context) ([]models.Ranking, error) {
+func GetRankings(client *mongo.Client, c context.Context) ([]models.Ranking, error) {
 	var rankings []models.Ranking
 
 	var ctx, cancel = context.WithTimeout(c, 100*time.Second)
@@ -265,7 +375,9 @@ func GetRankings(client *mongo.Client, c *gin.Context) ([]models.Ranking, error)
 
 	var rankingCollection = database.OpenCollection("rankings", client)
 
-	cursor, err := rankingCollection.Find(ctx, bson.D{})
+	findOptions := options.Find().SetSort(bson.D{{Key: "ranking_value", Value: 1}})
+
+	cursor, err := rankingCollection.Find(ctx, bson.D{}, findOptions)
 
 	if err != nil {
 		return nil, err
@@ -288,7 +400,7 @@ func GetRecommendedMovies(client *mongo.Client) gin.HandlerFunc {
 			return
 		}
 
-		favourite_genres, err := GetUsersFavouriteGenres(userId, client, c)
+		favourite_genres, err := GetUsersFavouriteGenres(userId, c.GetString("profileId"), client, c)
 		if err != nil {
 			log.Println("Error fetching user's favourite genres:", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -308,14 +420,22 @@ func GetRecommendedMovies(client *mongo.Client) gin.HandlerFunc {
 		findOptions.SetSort(bson.D{{Key: "ranking.ranking_value", Value: 1}})
 		findOptions.SetLimit(recommendedMovieLimitVal)
 
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		limit, err := maturityLimit(ctx, client, c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error loading parental controls"})
+			return
+		}
+
 		filter := bson.D{
 			{Key: "genre.genre_name", Value: bson.D{
 				{Key: "$in", Value: favourite_genres},
 			}},
+			notDeleted,
 		}
-
-		ctx, cancel := context.WithTimeout(c, 100*time.Second)
-		defer cancel()
+		filter = append(filter, maturityScope(limit)...)
 
 		movieCollection := database.OpenCollection("movies", client)
 		cursor, err := movieCollection.Find(ctx, filter, findOptions)
@@ -337,17 +457,30 @@ func GetRecommendedMovies(client *mongo.Client) gin.HandlerFunc {
 	}
 }
 
-func GetUsersFavouriteGenres(userId string, client *mongo.Client, c *gin.Context) ([]string, error) {
+// GetUsersFavouriteGenres returns the genre names recommendations are based
+// on: the selected household profile's, or the account's when no profile is
+// selected or it no longer exists.
+func GetUsersFavouriteGenres(userId, profileId string, client *mongo.Client, c *gin.Context) ([]string, error) {
 	ctx, cancel := context.WithTimeout(c, 100*time.Second)
 	defer cancel()
 
 	filter := bson.D{{Key: "user_id", Value: userId}}
-	projection := bson.M{"favourite_genres.genre_name": 1, "_id": 0}
+	projection := bson.M{
+		"favourite_genres.genre_name":          1,
+		"profiles.profile_id":                  1,
+		"profiles.favourite_genres.genre_name": 1,
+		"_id":                                  0,
+	}
 
+	type genreList []struct {
+		GenreName string `bson:"genre_name"`
+	}
 	var result struct {
-		FavouriteGenres []struct {
-			GenreName string `bson:"genre_name"`
-		} `bson:"favourite_genres"`
+		FavouriteGenres genreList `bson:"favourite_genres"`
+		Profiles        []struct {
+			ProfileID       string    `bson:"profile_id"`
+			FavouriteGenres genreList `bson:"favourite_genres"`
+		} `bson:"profiles"`
 	}
 
 	userCollection := database.OpenCollection("users", client)
@@ -359,8 +492,16 @@ func GetUsersFavouriteGenres(userId string, client *mongo.Client, c *gin.Context
 		return nil, err
 	}
 
-	genreNames := make([]string, 0, len(result.FavouriteGenres))
-	for _, g := range result.FavouriteGenres {
+	favourites := result.FavouriteGenres
+	for _, p := range result.Profiles {
+		if profileId != "" && p.ProfileID == profileId {
+			favourites = p.FavouriteGenres
+			break
+		}
+	}
+
+	genreNames := make([]string, 0, len(favourites))
+	for _, g := range favourites {
 		genreNames = append(genreNames, g.GenreName)
 	}
 
@@ -373,7 +514,9 @@ func GetGenres(client *mongo.Client) gin.HandlerFunc {
 
 		genreCollection := database.OpenCollection("genres", client)
 
-		cursor, err := genreCollection.Find(ctx, bson.D{})
+		findOptions := options.Find().SetSort(bson.D{{Key: "position", Value: 1}, {Key: "genre_id", Value: 1}})
+
+		cursor, err := genreCollection.Find(ctx, bson.D{}, findOptions)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"status":  "fail",
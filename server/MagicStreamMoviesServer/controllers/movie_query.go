@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const maxMoviePageSize = 100
+
+// movieSorts maps the public ?sort= values to MongoDB sort documents.
+// imdb_id is always appended as a tie-breaker so pages are stable.
+var movieSorts = map[string]bson.D{
+	"title":    {{Key: "title", Value: 1}, {Key: "imdb_id", Value: 1}},
+	"-title":   {{Key: "title", Value: -1}, {Key: "imdb_id", Value: 1}},
+	"ranking":  {{Key: "ranking.ranking_value", Value: 1}, {Key: "imdb_id", Value: 1}},
+	"-ranking": {{Key: "ranking.ranking_value", Value: -1}, {Key: "imdb_id", Value: 1}},
+}
+
+// defaultMoviePageSize reads MOVIES_PAGE_SIZE, falling back to 20.
+func defaultMoviePageSize() int64 {
+	if pageSizeStr := os.Getenv("MOVIES_PAGE_SIZE"); pageSizeStr != "" {
+		if val, err := strconv.ParseInt(pageSizeStr, 10, 64); err == nil && val > 0 && val <= maxMoviePageSize {
+			return val
+		}
+	}
+	return 20
+}
+
+// parsePagination reads ?page= and ?page_size= from the request.
+func parsePagination(c *gin.Context) (int64, int64, error) {
+	page := int64(1)
+	pageSize := defaultMoviePageSize()
+
+	if pageStr := c.Query("page"); pageStr != "" {
+		val, err := strconv.ParseInt(pageStr, 10, 64)
+		if err != nil || val < 1 {
+			return 0, 0, errors.New("page must be a positive integer")
+		}
+		page = val
+	}
+
+	if pageSizeStr := c.Query("page_size"); pageSizeStr != "" {
+		val, err := strconv.ParseInt(pageSizeStr, 10, 64)
+		if err != nil || val < 1 || val > maxMoviePageSize {
+			return 0, 0, errors.New("page_size must be between 1 and " + strconv.Itoa(maxMoviePageSize))
+		}
+		pageSize = val
+	}
+
+	return page, pageSize, nil
+}
+
+// notDeleted matches movies that have not been soft deleted.
+var notDeleted = bson.E{Key: "deleted_at", Value: bson.D{{Key: "$exists", Value: false}}}
+
+// buildMovieFilter turns the ?genre=, ?ranking=, ?ranking_value= and ?title=
+// query parameters into a MongoDB filter. Soft-deleted movies are excluded.
+func buildMovieFilter(c *gin.Context) (bson.D, error) {
+	filter := bson.D{notDeleted}
+
+	if genres := splitQueryList(c.Query("genre")); len(genres) > 0 {
+		filter = append(filter, bson.E{Key: "genre.genre_name", Value: bson.D{{Key: "$in", Value: genres}}})
+	}
+
+	if rankingName := strings.TrimSpace(c.Query("ranking")); rankingName != "" {
+		filter = append(filter, bson.E{Key: "ranking.ranking_name", Value: rankingName})
+	}
+
+	if rankingValueStr := c.Query("ranking_value"); rankingValueStr != "" {
+		rankingValue, err := strconv.Atoi(rankingValueStr)
+		if err != nil {
+			return nil, errors.New("ranking_value must be an integer")
+		}
+		filter = append(filter, bson.E{Key: "ranking.ranking_value", Value: rankingValue})
+	}
+
+	if title := strings.TrimSpace(c.Query("title")); title != "" {
+		filter = append(filter, bson.E{Key: "title", Value: bson.D{
+			{Key: "$regex", Value: "^" + regexp.QuoteMeta(title)},
+			{Key: "$options", Value: "i"},
+		}})
+	}
+
+	return filter, nil
+}
+
+// parseMovieSort resolves ?sort= against movieSorts, defaulting to title.
+func parseMovieSort(c *gin.Context) (bson.D, error) {
+	sortKey := c.DefaultQuery("sort", "title")
+	sort, ok := movieSorts[sortKey]
+	if !ok {
+		return nil, errors.New("sort must be one of title, -title, ranking, -ranking")
+	}
+	return sort, nil
+}
+
+// pageLink returns the current request URL with ?page= replaced.
+func pageLink(c *gin.Context, page int64) string {
+	link := url.URL{Path: c.Request.URL.Path}
+	query := c.Request.URL.Query()
+	query.Set("page", strconv.FormatInt(page, 10))
+	link.RawQuery = query.Encode()
+	return link.String()
+}
+
+func splitQueryList(raw string) []string {
+	var values []string
+	for _, v := range strings.Split(raw, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,254 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/database"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/models"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/throttle"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Maturity filtering. Every movie read works out the viewer's limit with
+// maturityLimit: the selected profile's own limit, KIDS_MAX_MATURITY for
+// kids profiles without one, then the account's limit. Requests without a
+// valid token get ANONYMOUS_MAX_MATURITY, unrestricted by default. Once a
+// limit applies, unrated movies are hidden along with those rated above it.
+
+var parentalPINPattern = regexp.MustCompile(`^[0-9]{4,8}$`)
+
+// pinPolicy limits guesses at a parental PIN per account.
+var pinPolicy = throttle.Policy{
+	MaxFailures: 5,
+	Window:      15 * time.Minute,
+	Lockout:     15 * time.Minute,
+}
+
+// applyCertification normalizes movie.Certification and derives
+// MaturityAge from it.
+func applyCertification(movie *models.Movie) error {
+	movie.MaturityAge = nil
+	if movie.Certification == "" {
+		return nil
+	}
+
+	label, age, err := utils.ParseCertification(movie.Certification)
+	if err != nil {
+		return err
+	}
+	movie.Certification = label
+	movie.MaturityAge = &age
+	return nil
+}
+
+// parseMaturitySetting turns a certification from a settings request into
+// an age limit; "" means no limit.
+func parseMaturitySetting(raw string) (*int, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	_, age, err := utils.ParseCertification(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &age, nil
+}
+
+func maturityFromEnv(name, fallback string) *int {
+	raw := os.Getenv(name)
+	if raw == "" {
+		raw = fallback
+	}
+	limit, err := parseMaturitySetting(raw)
+	if err != nil {
+		log.Println("Invalid", name+":", err)
+		limit, _ = parseMaturitySetting(fallback)
+	}
+	return limit
+}
+
+// profileMaturityLimit is the limit for a profile, before the account's.
+func profileMaturityLimit(profile *models.Profile) *int {
+	if profile.MaxMaturity != nil {
+		return profile.MaxMaturity
+	}
+	if profile.Kids {
+		return maturityFromEnv("KIDS_MAX_MATURITY", "PG")
+	}
+	return nil
+}
+
+// userMaturityLimit is the limit for the user with profileId selected.
+func userMaturityLimit(user *models.User, profileId string) *int {
+	if profile := user.FindProfile(profileId); profile != nil {
+		if limit := profileMaturityLimit(profile); limit != nil {
+			return limit
+		}
+	}
+	return user.MaxMaturity
+}
+
+// maturityLimit returns the age limit for the caller, or nil for none.
+func maturityLimit(ctx context.Context, client *mongo.Client, c *gin.Context) (*int, error) {
+	userId := c.GetString("userId")
+	if userId == "" {
+		return maturityFromEnv("ANONYMOUS_MAX_MATURITY", ""), nil
+	}
+
+	var user models.User
+	err := database.OpenCollection("users", client).FindOne(ctx,
+		bson.M{"user_id": userId},
+		options.FindOne().SetProjection(bson.M{"max_maturity": 1, "profiles": 1}),
+	).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+	return userMaturityLimit(&user, c.GetString("profileId")), nil
+}
+
+// maturityScope returns the filter elements hiding movies above limit.
+func maturityScope(limit *int) bson.D {
+	if limit == nil {
+		return nil
+	}
+	return bson.D{{Key: "maturity_age", Value: bson.D{{Key: "$lte", Value: *limit}}}}
+}
+
+func movieAllowed(movie *models.Movie, limit *int) bool {
+	return limit == nil || (movie.MaturityAge != nil && *movie.MaturityAge <= *limit)
+}
+
+// looserThan reports whether limit a lets through more than limit b.
+func looserThan(a, b *int) bool {
+	if b == nil {
+		return false
+	}
+	return a == nil || *a > *b
+}
+
+// checkParentalPIN verifies pin against the account's parental PIN, if it
+// has one. On failure it writes the error response and returns false.
+func checkParentalPIN(c *gin.Context, ctx context.Context, user *models.User, pin string) bool {
+	if user.ParentalPIN == "" {
+		return true
+	}
+
+	key := "pin:" + user.UserID
+	store := throttle.Default()
+	if status, err := store.Status(ctx, key, pinPolicy); err == nil && status.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(status.RetryAfter.Seconds())+1))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many wrong PINs. Please try again later."})
+		return false
+	}
+
+	if pin == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Parental PIN required", "pin_required": true})
+		return false
+	}
+	if ok, _ := utils.VerifyPassword(user.ParentalPIN, pin); !ok {
+		if _, err := store.RecordFailure(ctx, key, pinPolicy); err != nil {
+			log.Println("Failed to record parental PIN failure:", err)
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "Wrong parental PIN", "pin_required": true})
+		return false
+	}
+
+	if err := store.Reset(ctx, key); err != nil {
+		log.Println("Failed to reset parental PIN failures:", err)
+	}
+	return true
+}
+
+// UpdateParentalControls sets the account-wide maturity limit and the
+// parental PIN. Once a PIN is set, it is needed to change either.
+func UpdateParentalControls(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var req struct {
+			MaxMaturity *string `json:"maxMaturity"`
+			PIN         string  `json:"pin"`
+			NewPIN      *string `json:"newPin"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+			return
+		}
+
+		user, ok := profileOwner(c, ctx, client)
+		if !ok {
+			return
+		}
+		if !checkParentalPIN(c, ctx, user, req.PIN) {
+			return
+		}
+
+		set := bson.M{}
+		unset := bson.M{}
+		if req.MaxMaturity != nil {
+			limit, err := parseMaturitySetting(*req.MaxMaturity)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if limit == nil {
+				unset["max_maturity"] = ""
+			} else {
+				set["max_maturity"] = *limit
+			}
+		}
+		if req.NewPIN != nil {
+			if *req.NewPIN == "" {
+				unset["parental_pin"] = ""
+			} else {
+				if !parentalPINPattern.MatchString(*req.NewPIN) {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "PIN must be 4 to 8 digits"})
+					return
+				}
+				hashedPIN, err := utils.HashPassword(*req.NewPIN)
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set PIN"})
+					return
+				}
+				set["parental_pin"] = hashedPIN
+			}
+		}
+		if len(set) == 0 && len(unset) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to update"})
+			return
+		}
+		set["updated_at"] = time.Now()
+
+		update := bson.M{"$set": set}
+		if len(unset) > 0 {
+			update["$unset"] = unset
+		}
+
+		err := database.OpenCollection("users", client).FindOneAndUpdate(ctx,
+			bson.M{"user_id": user.UserID},
+			update,
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(user)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update parental controls"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":        "Parental controls updated",
+			"maxMaturity":    user.MaxMaturity,
+			"parentalPinSet": user.ParentalPIN != "",
+		})
+	}
+}
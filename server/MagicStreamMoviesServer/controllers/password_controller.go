@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/database"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/models"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ChangePassword sets a new password for the logged-in user after checking
+// the current one. Every other session is logged out; the one making the
+// change stays logged in.
+func ChangePassword(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var req struct {
+			CurrentPassword string `json:"current_password" validate:"required"`
+			NewPassword     string `json:"new_password" validate:"required,min=8,max=20"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			accountReply(c, http.StatusBadRequest, "Invalid input data")
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			accountReply(c, http.StatusBadRequest, "Validation failed: "+err.Error())
+			return
+		}
+
+		var user models.User
+		err := database.OpenCollection("users", client).FindOne(ctx, bson.M{"user_id": c.GetString("userId")}).Decode(&user)
+		if err != nil {
+			accountReply(c, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		// A stolen access token must not become a way to guess the password
+		if loginThrottled(c, ctx, user.Email) {
+			return
+		}
+		if ok, _ := utils.VerifyPassword(user.Password, req.CurrentPassword); !ok {
+			recordLoginFailure(c, ctx, client, user.Email)
+			accountReply(c, http.StatusUnauthorized, "Current password is incorrect")
+			return
+		}
+		resetLoginFailures(ctx, user.Email)
+
+		hashedPassword, err := utils.HashPassword(req.NewPassword)
+		if err != nil {
+			accountReply(c, http.StatusInternalServerError, "Unable to hash password")
+			return
+		}
+
+		_, err = database.OpenCollection("users", client).UpdateOne(ctx,
+			bson.M{"user_id": user.UserID},
+			bson.M{"$set": bson.M{"password": hashedPassword, "updated_at": time.Now()}},
+		)
+		if err != nil {
+			accountReply(c, http.StatusInternalServerError, "Failed to change password")
+			return
+		}
+
+		err = utils.RevokeOtherSessions(ctx, client, user.UserID, c.GetString("sessionId"), models.SessionRevokedPasswordChange)
+		if err != nil {
+			log.Println("Failed to revoke other sessions after password change:", err)
+		}
+
+		accountReply(c, http.StatusOK, "Password changed. Other devices have been logged out.")
+	}
+}
+
+// upgradePasswordHash replaces a hash made with an older algorithm or
+// weaker parameters, now that the plain password is known to be right. The
+// update only applies if the stored hash is still the one that was checked,
+// so it cannot undo a concurrent password change.
+func upgradePasswordHash(ctx context.Context, client *mongo.Client, user *models.User, password string) {
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		log.Println("Failed to rehash password:", err)
+		return
+	}
+
+	_, err = database.OpenCollection("users", client).UpdateOne(ctx,
+		bson.M{"user_id": user.UserID, "password": user.Password},
+		bson.M{"$set": bson.M{"password": hashedPassword}},
+	)
+	if err != nil {
+		log.Println("Failed to store upgraded password hash:", err)
+		return
+	}
+	user.Password = hashedPassword
+}
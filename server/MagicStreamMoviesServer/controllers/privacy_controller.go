@@ -0,0 +1,345 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/database"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/jobs"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/models"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/throttle"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DeleteAccountJobType anonymizes an account once its deletion grace period
+// is over.
+const DeleteAccountJobType = "delete_account"
+
+// accountDeletionGrace reads ACCOUNT_DELETION_GRACE_HOURS, falling back to
+// 14 days.
+func accountDeletionGrace() time.Duration {
+	return time.Duration(utils.EnvInt("ACCOUNT_DELETION_GRACE_HOURS", 14*24)) * time.Hour
+}
+
+// ExportMe returns everything stored about the logged-in user: the profile,
+// sessions, reviews written and security events. ?format=zip packs each
+// part into its own JSON file.
+func ExportMe(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		export, err := buildUserExport(ctx, client, c.GetString("userId"))
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export data"})
+			return
+		}
+
+		filename := "magicstream-export-" + export.Profile.UserID
+		switch c.DefaultQuery("format", "json") {
+		case "json":
+			c.Header("Content-Disposition", `attachment; filename="`+filename+`.json"`)
+			c.JSON(http.StatusOK, export)
+		case "zip":
+			archive, err := zipUserExport(export)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export data"})
+				return
+			}
+			c.Header("Content-Disposition", `attachment; filename="`+filename+`.zip"`)
+			c.Data(http.StatusOK, "application/zip", archive)
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or zip"})
+		}
+	}
+}
+
+func buildUserExport(ctx context.Context, client *mongo.Client, userId string) (*models.UserExport, error) {
+	var user models.User
+	if err := database.OpenCollection("users", client).FindOne(ctx, bson.M{"user_id": userId}).Decode(&user); err != nil {
+		return nil, err
+	}
+
+	export := &models.UserExport{
+		ExportedAt:     time.Now(),
+		Profile:        models.NewUserSummary(&user),
+		Sessions:       []models.Session{},
+		Reviews:        []models.MovieAudit{},
+		SecurityEvents: []models.AuthAudit{},
+	}
+
+	newestFirst := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	parts := []struct {
+		collection string
+		filter     bson.M
+		into       interface{}
+	}{
+		{"sessions", bson.M{"user_id": userId}, &export.Sessions},
+		{"movie_audit", bson.M{"actor_id": userId, "action": models.MovieAuditReview}, &export.Reviews},
+		{"auth_audit", bson.M{"$or": bson.A{
+			bson.M{"email": auditEmailFilter(user.Email)},
+			bson.M{"key": "user:" + userId},
+		}}, &export.SecurityEvents},
+	}
+	for _, part := range parts {
+		cursor, err := database.OpenCollection(part.collection, client).Find(ctx, part.filter, newestFirst)
+		if err != nil {
+			return nil, err
+		}
+		if err := cursor.All(ctx, part.into); err != nil {
+			return nil, err
+		}
+	}
+
+	return export, nil
+}
+
+func zipUserExport(export *models.UserExport) ([]byte, error) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"sessions.json", export.Sessions},
+		{"reviews.json", export.Reviews},
+		{"security_events.json", export.SecurityEvents},
+	}
+	for _, file := range files {
+		w, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DeleteMe schedules the logged-in user's account for deletion after the
+// grace period and logs it out everywhere. Logging in again and calling
+// CancelDeleteMe keeps the account.
+func DeleteMe(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var req struct {
+			Password string `json:"password"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || req.Password == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "password is required"})
+			return
+		}
+
+		userCollection := database.OpenCollection("users", client)
+
+		var user models.User
+		if err := userCollection.FindOne(ctx, bson.M{"user_id": c.GetString("userId")}).Decode(&user); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			return
+		}
+		if loginThrottled(c, ctx, user.Email) {
+			return
+		}
+		if ok, _ := utils.VerifyPassword(user.Password, req.Password); !ok {
+			recordLoginFailure(c, ctx, client, user.Email)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password"})
+			return
+		}
+		if user.DeletionScheduledFor != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Account deletion is already scheduled", "scheduled_for": user.DeletionScheduledFor})
+			return
+		}
+
+		now := time.Now()
+		scheduledFor := now.Add(accountDeletionGrace())
+
+		job, err := jobs.EnqueueAt(ctx, client, DeleteAccountJobType, map[string]interface{}{
+			"user_id": user.UserID,
+		}, scheduledFor)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule account deletion"})
+			return
+		}
+
+		_, err = userCollection.UpdateOne(ctx,
+			bson.M{"user_id": user.UserID},
+			bson.M{"$set": bson.M{
+				"deletion_requested_at":  now,
+				"deletion_scheduled_for": scheduledFor,
+				"deletion_job_id":        job.ID.Hex(),
+				"updated_at":             now,
+			}},
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule account deletion"})
+			return
+		}
+
+		if err := utils.BumpTokenVersion(ctx, client, user.UserID); err != nil {
+			log.Println("Failed to log out user scheduled for deletion:", err)
+		}
+		if err := utils.RevokeAllSessions(ctx, client, user.UserID, models.SessionRevokedLogoutAll); err != nil {
+			log.Println("Failed to revoke sessions of user scheduled for deletion:", err)
+		}
+		clearAuthCookies(c)
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"message":       "Account scheduled for deletion. Log in again before then to cancel.",
+			"scheduled_for": scheduledFor,
+			"job_id":        job.ID.Hex(),
+		})
+	}
+}
+
+// CancelDeleteMe keeps an account whose deletion is still in its grace
+// period. The queued job finds nothing to do when it runs.
+func CancelDeleteMe(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		result, err := database.OpenCollection("users", client).UpdateOne(ctx,
+			bson.M{"user_id": c.GetString("userId"), "deletion_scheduled_for": bson.M{"$exists": true}},
+			bson.M{
+				"$set":   bson.M{"updated_at": time.Now()},
+				"$unset": bson.M{"deletion_requested_at": "", "deletion_scheduled_for": "", "deletion_job_id": ""},
+			},
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel account deletion"})
+			return
+		}
+		if result.MatchedCount == 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "No account deletion is scheduled"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Account deletion cancelled"})
+	}
+}
+
+// DeleteAccountJob removes a user whose deletion is due. The user document is
+// replaced by a tombstone, everything else keyed by the user is deleted, and
+// the email is scrubbed from auth_audit. movie_audit keeps the user id, which
+// the tombstone still resolves.
+func DeleteAccountJob(client *mongo.Client) jobs.Handler {
+	return func(ctx context.Context, job *models.Job) (map[string]interface{}, error) {
+		userId, _ := job.Payload["user_id"].(string)
+		if userId == "" {
+			return nil, jobs.Permanent(errors.New("payload is missing user_id"))
+		}
+
+		userCollection := database.OpenCollection("users", client)
+
+		var user models.User
+		err := userCollection.FindOne(ctx, bson.M{"user_id": userId}).Decode(&user)
+		if err == mongo.ErrNoDocuments {
+			return map[string]interface{}{"skipped": "user already deleted"}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Cancelled, or rescheduled by a newer request
+		if user.DeletionScheduledFor == nil || user.DeletionJobID != job.ID.Hex() {
+			return map[string]interface{}{"skipped": "deletion was cancelled"}, nil
+		}
+		if user.DeletionScheduledFor.After(time.Now()) {
+			return nil, fmt.Errorf("deletion of %s is not due until %s", userId, user.DeletionScheduledFor)
+		}
+
+		now := time.Now()
+		_, err = database.OpenCollection("user_tombstones", client).UpdateOne(ctx,
+			bson.M{"user_id": userId},
+			bson.M{"$setOnInsert": models.UserTombstone{
+				UserID:      userId,
+				Role:        user.Role,
+				CreatedAt:   user.CreatedAt,
+				RequestedAt: user.DeletionRequestedAt,
+				DeletedAt:   now,
+			}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		deleted := make(map[string]interface{})
+		for _, collectionName := range []string{"sessions", "user_tokens"} {
+			result, err := database.OpenCollection(collectionName, client).DeleteMany(ctx, bson.M{"user_id": userId})
+			if err != nil {
+				return nil, err
+			}
+			deleted[collectionName] = result.DeletedCount
+		}
+
+		placeholder := "deleted-user:" + userId
+		auditCollection := database.OpenCollection("auth_audit", client)
+		if _, err := auditCollection.UpdateMany(ctx,
+			bson.M{"key": throttle.EmailKey(user.Email)},
+			bson.M{"$set": bson.M{"key": placeholder}},
+		); err != nil {
+			return nil, err
+		}
+		scrubbed, err := auditCollection.UpdateMany(ctx,
+			bson.M{"email": auditEmailFilter(user.Email)},
+			bson.M{"$set": bson.M{"email": placeholder}},
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := throttle.Default().Reset(ctx, throttle.EmailKey(user.Email)); err != nil {
+			log.Println("Failed to clear login throttle for deleted user:", err)
+		}
+		if err := throttle.Default().Reset(ctx, mailThrottleKey(user.Email)); err != nil {
+			log.Println("Failed to clear mail throttle for deleted user:", err)
+		}
+
+		if _, err := userCollection.DeleteOne(ctx, bson.M{"user_id": userId}); err != nil {
+			return nil, err
+		}
+
+		log.Println("Deleted account", userId)
+		return map[string]interface{}{
+			"user_id":             userId,
+			"deleted":             deleted,
+			"auth_audit_scrubbed": scrubbed.ModifiedCount,
+		}, nil
+	}
+}
+
+// auditEmailFilter matches auth_audit entries for email both as stored on
+// the account and as normalised by the login throttle.
+func auditEmailFilter(email string) bson.M {
+	return bson.M{"$in": bson.A{email, strings.ToLower(strings.TrimSpace(email))}}
+}
@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/database"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/models"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GetMe returns the logged-in user's profile.
+func GetMe(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var user models.User
+		err := database.OpenCollection("users", client).FindOne(ctx, bson.M{"user_id": c.GetString("userId")}).Decode(&user)
+		if err != nil {
+			accountReply(c, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		c.JSON(http.StatusOK, models.APIResponse{
+			Status:    "success",
+			Error:     false,
+			Message:   "Profile retrieved",
+			Content:   models.NewUserContent(&user),
+			Timestamp: time.Now(),
+		})
+	}
+}
+
+// UpdateMe edits the logged-in user's names and favourite genres. A new
+// email address needs the current password and is not applied straight
+// away: it is kept as pending and a verification link is sent to it, and
+// VerifyEmail swaps it in. The old address is told about the change.
+func UpdateMe(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var req models.UserUpdate
+		if err := c.ShouldBindJSON(&req); err != nil {
+			accountReply(c, http.StatusBadRequest, "Invalid input data")
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			accountReply(c, http.StatusBadRequest, "Validation failed: "+err.Error())
+			return
+		}
+
+		userCollection := database.OpenCollection("users", client)
+
+		var user models.User
+		if err := userCollection.FindOne(ctx, bson.M{"user_id": c.GetString("userId")}).Decode(&user); err != nil {
+			accountReply(c, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		changingEmail := req.Email != nil && !strings.EqualFold(*req.Email, user.Email)
+		if changingEmail {
+			// Whoever controls the email can reset the password, so this is
+			// guarded like ChangePassword
+			if req.CurrentPassword == "" {
+				accountReply(c, http.StatusBadRequest, "Current password is required to change the email address")
+				return
+			}
+			if loginThrottled(c, ctx, user.Email) {
+				return
+			}
+			if ok, _ := utils.VerifyPassword(user.Password, req.CurrentPassword); !ok {
+				recordLoginFailure(c, ctx, client, user.Email)
+				accountReply(c, http.StatusUnauthorized, "Current password is incorrect")
+				return
+			}
+			resetLoginFailures(ctx, user.Email)
+		}
+
+		set := bson.M{}
+		if req.FirstName != nil {
+			set["first_name"] = strings.TrimSpace(*req.FirstName)
+		}
+		if req.LastName != nil {
+			set["last_name"] = strings.TrimSpace(*req.LastName)
+		}
+		if req.FavouriteGenres != nil {
+			genres, err := resolveGenres(ctx, client, *req.FavouriteGenres)
+			if err != nil {
+				accountReply(c, http.StatusBadRequest, err.Error())
+				return
+			}
+			set["favourite_genres"] = genres
+		}
+
+		var newEmail string
+		if changingEmail {
+			newEmail = strings.TrimSpace(*req.Email)
+			count, err := userCollection.CountDocuments(ctx, bson.M{"email": newEmail})
+			if err != nil {
+				accountReply(c, http.StatusInternalServerError, "Failed to check email")
+				return
+			}
+			if count > 0 {
+				accountReply(c, http.StatusConflict, "That email address is already in use")
+				return
+			}
+			set["pending_email"] = newEmail
+		}
+
+		if len(set) > 0 {
+			set["updated_at"] = time.Now()
+			err := userCollection.FindOneAndUpdate(ctx,
+				bson.M{"user_id": user.UserID},
+				bson.M{"$set": set},
+				options.FindOneAndUpdate().SetReturnDocument(options.After),
+			).Decode(&user)
+			if err != nil {
+				accountReply(c, http.StatusInternalServerError, "Failed to update profile")
+				return
+			}
+		}
+
+		message := "Profile updated"
+		if newEmail != "" {
+			sendAccountEmail(client, user.UserID, newEmail, models.UserTokenEmailVerification)
+			sendEmailChangeNotice(user.Email, newEmail)
+			message = "Profile updated. Follow the link sent to " + newEmail + " to confirm the new address."
+		}
+
+		c.JSON(http.StatusOK, models.APIResponse{
+			Status:    "success",
+			Error:     false,
+			Message:   message,
+			Content:   models.NewUserContent(&user),
+			Timestamp: time.Now(),
+		})
+	}
+}
+
+// resolveGenres checks the requested genres against the genres collection
+// by id and returns them with their current names, without duplicates.
+func resolveGenres(ctx context.Context, client *mongo.Client, requested []models.Genre) ([]models.Genre, error) {
+	ids := make([]int, 0, len(requested))
+	seen := make(map[int]bool, len(requested))
+	for _, g := range requested {
+		if !seen[g.GenreID] {
+			seen[g.GenreID] = true
+			ids = append(ids, g.GenreID)
+		}
+	}
+
+	cursor, err := database.OpenCollection("genres", client).Find(ctx, bson.M{"genre_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up genres")
+	}
+	var known []models.Genre
+	if err := cursor.All(ctx, &known); err != nil {
+		return nil, fmt.Errorf("failed to look up genres")
+	}
+
+	byId := make(map[int]string, len(known))
+	for _, g := range known {
+		byId[g.GenreID] = g.GenreName
+	}
+
+	genres := make([]models.Genre, 0, len(ids))
+	for _, id := range ids {
+		name, ok := byId[id]
+		if !ok {
+			return nil, fmt.Errorf("unknown genre id %d", id)
+		}
+		genres = append(genres, models.Genre{GenreID: id, GenreName: name})
+	}
+	return genres, nil
+}
@@ -0,0 +1,428 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/database"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/jobs"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RerankCatalogJobType re-classifies every reviewed movie in the background.
+const RerankCatalogJobType = "rerank_catalog"
+
+// ErrRerankInProgress is returned when a new run is requested while another
+// one has not finished.
+var ErrRerankInProgress = errors.New("a re-rank run is already in progress")
+
+// RerankOptions bounds how hard a re-rank run hits the classifier.
+type RerankOptions struct {
+	Concurrency int
+	RatePerSec  float64
+}
+
+// DefaultRerankOptions reads RERANK_CONCURRENCY (default 4) and
+// RERANK_RATE_PER_SECOND (default 2, 0 disables rate limiting).
+func DefaultRerankOptions() RerankOptions {
+	opts := RerankOptions{Concurrency: 4, RatePerSec: 2}
+	if val, err := strconv.Atoi(os.Getenv("RERANK_CONCURRENCY")); err == nil && val > 0 {
+		opts.Concurrency = val
+	}
+	if val, err := strconv.ParseFloat(os.Getenv("RERANK_RATE_PER_SECOND"), 64); err == nil && val >= 0 {
+		opts.RatePerSec = val
+	}
+	return opts
+}
+
+func StartRerank(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		opts := DefaultRerankOptions()
+		if val, err := strconv.Atoi(c.Query("concurrency")); err == nil && val > 0 {
+			opts.Concurrency = val
+		}
+		if val, err := strconv.ParseFloat(c.Query("rate_per_sec"), 64); err == nil && val >= 0 {
+			opts.RatePerSec = val
+		}
+
+		run, err := CreateRerankRun(ctx, client, opts)
+		if err != nil {
+			if errors.Is(err, ErrRerankInProgress) {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "run_id": run.ID.Hex()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create re-rank run"})
+			return
+		}
+
+		job, err := enqueueRerank(ctx, client, run.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule re-rank run"})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"run_id":     run.ID.Hex(),
+			"job_id":     job.ID.Hex(),
+			"status_url": "/movies/rerank/" + run.ID.Hex(),
+		})
+	}
+}
+
+// GetRerankRun reports the progress and ranking diff of a run.
+func GetRerankRun(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		runId, err := primitive.ObjectIDFromHex(c.Param("run_id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Re-rank run not found"})
+			return
+		}
+
+		run, err := loadRerankRun(ctx, client, runId)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Re-rank run not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch re-rank run"})
+			return
+		}
+
+		progress := 100.0
+		if run.Total > 0 {
+			progress = min(100, float64(run.Processed)/float64(run.Total)*100)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"run": run, "progress": progress})
+	}
+}
+
+// ResumeRerank re-queues a run that failed or whose job gave up, continuing
+// from its last checkpoint.
+func ResumeRerank(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		runId, err := primitive.ObjectIDFromHex(c.Param("run_id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Re-rank run not found"})
+			return
+		}
+
+		run, err := loadRerankRun(ctx, client, runId)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Re-rank run not found"})
+			return
+		}
+
+		if run.Status == models.RerankCompleted {
+			c.JSON(http.StatusConflict, gin.H{"error": "Re-rank run already completed"})
+			return
+		}
+		if run.JobID != "" {
+			if job, err := jobs.Get(ctx, client, run.JobID); err == nil &&
+				(job.Status == models.JobPending || job.Status == models.JobRunning) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Re-rank run is still active", "job_id": run.JobID})
+				return
+			}
+		}
+
+		job, err := enqueueRerank(ctx, client, run.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule re-rank run"})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"run_id":     run.ID.Hex(),
+			"job_id":     job.ID.Hex(),
+			"checkpoint": run.Checkpoint,
+			"status_url": "/movies/rerank/" + run.ID.Hex(),
+		})
+	}
+}
+
+// CreateRerankRun stores a new pending run. If another run is still pending
+// or running, that run is returned together with ErrRerankInProgress.
+func CreateRerankRun(ctx context.Context, client *mongo.Client, opts RerankOptions) (*models.RerankRun, error) {
+	runCollection := database.OpenCollection("rerank_runs", client)
+
+	var active models.RerankRun
+	err := runCollection.FindOne(ctx, bson.M{"status": bson.M{"$in": bson.A{models.RerankPending, models.RerankRunning}}}).Decode(&active)
+	if err == nil {
+		return &active, ErrRerankInProgress
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	now := time.Now()
+	run := &models.RerankRun{
+		Status:      models.RerankPending,
+		Concurrency: max(opts.Concurrency, 1),
+		RatePerSec:  opts.RatePerSec,
+		Changes:     []models.RankingChange{},
+		Errors:      []models.RerankError{},
+		StartedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	result, err := runCollection.InsertOne(ctx, run)
+	if err != nil {
+		return nil, err
+	}
+	run.ID = result.InsertedID.(primitive.ObjectID)
+
+	return run, nil
+}
+
+func enqueueRerank(ctx context.Context, client *mongo.Client, runId primitive.ObjectID) (*models.Job, error) {
+	job, err := jobs.Enqueue(ctx, client, RerankCatalogJobType, map[string]interface{}{"run_id": runId.Hex()})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = database.OpenCollection("rerank_runs", client).UpdateOne(ctx,
+		bson.M{"_id": runId},
+		bson.M{"$set": bson.M{"job_id": job.ID.Hex(), "status": models.RerankPending, "updated_at": time.Now()}},
+	)
+	return job, err
+}
+
+func loadRerankRun(ctx context.Context, client *mongo.Client, runId primitive.ObjectID) (*models.RerankRun, error) {
+	var run models.RerankRun
+	err := database.OpenCollection("rerank_runs", client).FindOne(ctx, bson.M{"_id": runId}).Decode(&run)
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// RerankCatalogJob runs RerankCatalog for the run in the job payload. A retry
+// of the job resumes from the run's checkpoint.
+func RerankCatalogJob(client *mongo.Client) jobs.Handler {
+	return func(ctx context.Context, job *models.Job) (map[string]interface{}, error) {
+		runIdHex, _ := job.Payload["run_id"].(string)
+		runId, err := primitive.ObjectIDFromHex(runIdHex)
+		if err != nil {
+			return nil, jobs.Permanent(errors.New("payload has an invalid run_id"))
+		}
+
+		run, err := RerankCatalog(ctx, client, runId)
+		if err != nil {
+			if jobs.IsLastAttempt(job) {
+				markRerankFailed(client, runId, err)
+			}
+			return nil, err
+		}
+
+		return map[string]interface{}{
+			"run_id":    run.ID.Hex(),
+			"processed": run.Processed,
+			"changed":   len(run.Changes),
+			"errors":    len(run.Errors),
+		}, nil
+	}
+}
+
+func markRerankFailed(client *mongo.Client, runId primitive.ObjectID, cause error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := database.OpenCollection("rerank_runs", client).UpdateOne(ctx,
+		bson.M{"_id": runId},
+		bson.M{"$set": bson.M{"status": models.RerankFailed, "last_error": cause.Error(), "updated_at": time.Now()}},
+	)
+	if err != nil {
+		log.Println("Failed to mark re-rank run as failed:", err)
+	}
+}
+
+type rerankResult struct {
+	movie   models.Movie
+	ranking models.Ranking
+	err     error
+}
+
+// RerankCatalog re-classifies every non-deleted movie that has an
+// admin_review, in imdb_id order, starting after the run's checkpoint.
+// Classification runs with bounded concurrency and a shared rate limit, and
+// the checkpoint and diff are saved after every batch. If ctx is cancelled
+// the run stays resumable from the last saved batch.
+func RerankCatalog(ctx context.Context, client *mongo.Client, runId primitive.ObjectID) (*models.RerankRun, error) {
+	runCollection := database.OpenCollection("rerank_runs", client)
+	movieCollection := database.OpenCollection("movies", client)
+
+	run, err := loadRerankRun(ctx, client, runId)
+	if err != nil {
+		return nil, err
+	}
+	if run.Status == models.RerankCompleted {
+		return run, nil
+	}
+
+	rankings, err := GetRankings(client, ctx)
+	if err != nil {
+		return nil, err
+	}
+	candidates := rankableRankings(rankings)
+	if len(candidates) == 0 {
+		return nil, errors.New("no rankings configured")
+	}
+
+	reviewed := bson.D{{Key: "admin_review", Value: bson.D{{Key: "$nin", Value: bson.A{"", nil}}}}, notDeleted}
+
+	set := bson.M{"status": models.RerankRunning, "last_error": "", "updated_at": time.Now()}
+	if run.Total == 0 {
+		total, err := movieCollection.CountDocuments(ctx, reviewed)
+		if err != nil {
+			return nil, err
+		}
+		set["total"] = total
+	}
+	if _, err := runCollection.UpdateOne(ctx, bson.M{"_id": runId}, bson.M{"$set": set}); err != nil {
+		return nil, err
+	}
+
+	concurrency := max(run.Concurrency, 1)
+	batchSize := int64(concurrency * 10)
+
+	var limiter <-chan time.Time
+	if run.RatePerSec > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / run.RatePerSec))
+		defer ticker.Stop()
+		limiter = ticker.C
+	}
+
+	checkpoint := run.Checkpoint
+	actorId := "rerank:" + runId.Hex()
+
+	for {
+		filter := append(bson.D{{Key: "imdb_id", Value: bson.D{{Key: "$gt", Value: checkpoint}}}}, reviewed...)
+		findOptions := options.Find().SetSort(bson.D{{Key: "imdb_id", Value: 1}}).SetLimit(batchSize)
+
+		cursor, err := movieCollection.Find(ctx, filter, findOptions)
+		if err != nil {
+			return nil, err
+		}
+		var batch []models.Movie
+		if err := cursor.All(ctx, &batch); err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		results := make([]rerankResult, len(batch))
+		sem := make(chan struct{}, concurrency)
+		var wg sync.WaitGroup
+
+		for i, movie := range batch {
+			sem <- struct{}{}
+			wg.Add(1)
+			go func(i int, movie models.Movie) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				results[i].movie = movie
+				if limiter != nil {
+					select {
+					case <-limiter:
+					case <-ctx.Done():
+						results[i].err = ctx.Err()
+						return
+					}
+				}
+				results[i].ranking, results[i].err = classifyReview(ctx, movie.AdminReview, candidates)
+			}(i, movie)
+		}
+		wg.Wait()
+
+		// Do not advance the checkpoint past a half-finished batch
+		if ctx.Err() != nil {
+			return run, ctx.Err()
+		}
+
+		changes := []models.RankingChange{}
+		rerankErrors := []models.RerankError{}
+		unchanged := 0
+
+		for _, result := range results {
+			movie := result.movie
+			if result.err != nil {
+				rerankErrors = append(rerankErrors, models.RerankError{ImdbID: movie.ImdbID, Error: result.err.Error()})
+				continue
+			}
+			if result.ranking == movie.Ranking {
+				unchanged++
+				continue
+			}
+
+			// Skip movies whose review was edited while we were classifying
+			updateResult, err := movieCollection.UpdateOne(ctx,
+				bson.D{{Key: "_id", Value: movie.ID}, {Key: "admin_review", Value: movie.AdminReview}},
+				bson.M{"$set": bson.M{"ranking": result.ranking, "ranking_status": models.RankingStatusRanked}},
+			)
+			if err != nil {
+				return nil, err
+			}
+			if updateResult.MatchedCount == 0 {
+				continue
+			}
+
+			after := movie
+			after.Ranking = result.ranking
+			after.RankingStatus = models.RankingStatusRanked
+			recordMovieAudit(ctx, client, models.MovieAuditRank, movie.ImdbID, actorId, &movie, &after)
+
+			changes = append(changes, models.RankingChange{
+				ImdbID: movie.ImdbID,
+				Title:  movie.Title,
+				Before: movie.Ranking,
+				After:  result.ranking,
+			})
+		}
+
+		checkpoint = batch[len(batch)-1].ImdbID
+		_, err = runCollection.UpdateOne(ctx, bson.M{"_id": runId}, bson.M{
+			"$set":  bson.M{"checkpoint": checkpoint, "updated_at": time.Now()},
+			"$inc":  bson.M{"processed": len(batch), "unchanged": unchanged},
+			"$push": bson.M{"changes": bson.M{"$each": changes}, "errors": bson.M{"$each": rerankErrors}},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		log.Printf("Re-rank %s: checkpoint %s, %d changed, %d errors in batch", runId.Hex(), checkpoint, len(changes), len(rerankErrors))
+	}
+
+	now := time.Now()
+	_, err = runCollection.UpdateOne(ctx, bson.M{"_id": runId}, bson.M{"$set": bson.M{
+		"status":       models.RerankCompleted,
+		"completed_at": now,
+		"updated_at":   now,
+	}})
+	if err != nil {
+		return nil, err
+	}
+
+	return loadRerankRun(ctx, client, runId)
+}
@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/classifier"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/database"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/jobs"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ClassifyReviewJobType ranks a movie's admin_review in the background.
+const ClassifyReviewJobType = "classify_review"
+
+// RegisterJobs registers every background job handler owned by controllers.
+func RegisterJobs(client *mongo.Client) {
+	jobs.Register(ClassifyReviewJobType, ClassifyReviewJob(client))
+	jobs.Register(RerankCatalogJobType, RerankCatalogJob(client))
+	jobs.Register(DeleteAccountJobType, DeleteAccountJob(client))
+}
+
+// ClassifyReviewJob runs the sentiment classifier over the review in the job
+// payload and stores the ranking, unless the review has been replaced since
+// the job was queued.
+func ClassifyReviewJob(client *mongo.Client) jobs.Handler {
+	return func(ctx context.Context, job *models.Job) (map[string]interface{}, error) {
+		movieId, _ := job.Payload["imdb_id"].(string)
+		review, _ := job.Payload["admin_review"].(string)
+		actorId, _ := job.Payload["actor_id"].(string)
+		if movieId == "" {
+			return nil, jobs.Permanent(errors.New("payload is missing imdb_id"))
+		}
+
+		movieCollection := database.OpenCollection("movies", client)
+		// Only touch the movie while this job's review is still the current one
+		filter := bson.D{
+			{Key: "imdb_id", Value: movieId},
+			{Key: "admin_review", Value: review},
+			{Key: "ranking_job_id", Value: job.ID.Hex()},
+			notDeleted,
+		}
+
+		var before models.Movie
+		if err := movieCollection.FindOne(ctx, filter).Decode(&before); err != nil {
+			if err == mongo.ErrNoDocuments {
+				return map[string]interface{}{"superseded": true}, nil
+			}
+			return nil, err
+		}
+
+		sentiment, rankVal, err := GetReviewRanking(review, client, ctx)
+		if err != nil {
+			// The classifier has already retried with a corrective prompt
+			if errors.Is(err, classifier.ErrUnmatchedRanking) {
+				markRankingFailed(ctx, client, filter)
+				return nil, jobs.Permanent(err)
+			}
+			if jobs.IsLastAttempt(job) {
+				markRankingFailed(ctx, client, filter)
+			}
+			return nil, err
+		}
+
+		ranking := models.Ranking{RankingValue: rankVal, RankingName: sentiment}
+		result, err := movieCollection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+			"ranking":        ranking,
+			"ranking_status": models.RankingStatusRanked,
+		}})
+		if err != nil {
+			return nil, err
+		}
+		if result.MatchedCount == 0 {
+			return map[string]interface{}{"superseded": true}, nil
+		}
+
+		after := before
+		after.Ranking = ranking
+		after.RankingStatus = models.RankingStatusRanked
+		recordMovieAudit(ctx, client, models.MovieAuditRank, movieId, actorId, &before, &after)
+
+		log.Println("Ranked review for", movieId, "as", sentiment)
+
+		return map[string]interface{}{
+			"imdb_id":       movieId,
+			"ranking_name":  sentiment,
+			"ranking_value": rankVal,
+		}, nil
+	}
+}
+
+func markRankingFailed(ctx context.Context, client *mongo.Client, filter bson.D) {
+	movieCollection := database.OpenCollection("movies", client)
+	_, err := movieCollection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"ranking_status": models.RankingStatusFailed}})
+	if err != nil {
+		log.Println("Failed to mark ranking as failed:", err)
+	}
+}
@@ -0,0 +1,269 @@
+package controllers
+
+import (
+	"context"
+	"html"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/database"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/models"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultSearchLimit = 10
+	maxSearchLimit     = 50
+
+	// Titles scoring below this are not considered fuzzy matches.
+	fuzzyMatchThreshold = 0.3
+
+	// Review highlights are cut down to roughly this many characters.
+	highlightSnippetLength = 160
+)
+
+var searchWordPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+func SearchMovies(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		query := strings.TrimSpace(c.Query("q"))
+		if query == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Search query q is required"})
+			return
+		}
+
+		limit := int64(defaultSearchLimit)
+		if limitStr := c.Query("limit"); limitStr != "" {
+			val, err := strconv.ParseInt(limitStr, 10, 64)
+			if err != nil || val < 1 || val > maxSearchLimit {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxSearchLimit)})
+				return
+			}
+			limit = val
+		}
+
+		maturity, err := maturityLimit(ctx, client, c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load parental controls"})
+			return
+		}
+		scope := append(bson.D{notDeleted}, maturityScope(maturity)...)
+
+		movieCollection := database.OpenCollection("movies", client)
+		terms := strings.Fields(utils.NormalizeText(query))
+
+		results, err := textSearchMovies(ctx, movieCollection, query, scope, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search movies"})
+			return
+		}
+
+		matchType := "text"
+		if len(results) == 0 {
+			matchType = "fuzzy"
+			results, err = fuzzySearchMovies(ctx, movieCollection, query, scope, limit)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search movies"})
+				return
+			}
+		}
+
+		for i := range results {
+			results[i].Highlights = highlightMovie(results[i].Movie, terms, matchType == "fuzzy")
+		}
+
+		c.JSON(http.StatusOK, models.MovieSearchResponse{
+			Query:     query,
+			MatchType: matchType,
+			Results:   results,
+		})
+	}
+}
+
+// textSearchMovies queries the movies_text index and orders hits by
+// MongoDB's relevance score. Only movies matching scope are searched.
+func textSearchMovies(ctx context.Context, movieCollection *mongo.Collection, query string, scope bson.D, limit int64) ([]models.MovieSearchResult, error) {
+	filter := append(bson.D{{Key: "$text", Value: bson.D{{Key: "$search", Value: query}}}}, scope...)
+	score := bson.D{{Key: "score", Value: bson.D{{Key: "$meta", Value: "textScore"}}}}
+
+	findOptions := options.Find()
+	findOptions.SetProjection(score)
+	findOptions.SetSort(score)
+	findOptions.SetLimit(limit)
+
+	cursor, err := movieCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var hits []struct {
+		models.Movie `bson:",inline"`
+		Score        float64 `bson:"score"`
+	}
+	if err := cursor.All(ctx, &hits); err != nil {
+		return nil, err
+	}
+
+	results := make([]models.MovieSearchResult, 0, len(hits))
+	for _, hit := range hits {
+		results = append(results, models.MovieSearchResult{Movie: hit.Movie, Score: hit.Score})
+	}
+
+	return results, nil
+}
+
+// fuzzySearchMovies scores every title against the query with trigram and
+// edit-distance similarity so that misspelled titles still find a match.
+// Only movies matching scope are considered.
+func fuzzySearchMovies(ctx context.Context, movieCollection *mongo.Collection, query string, scope bson.D, limit int64) ([]models.MovieSearchResult, error) {
+	findOptions := options.Find().SetProjection(bson.M{"imdb_id": 1, "title": 1, "_id": 0})
+
+	cursor, err := movieCollection.Find(ctx, scope, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var titles []struct {
+		ImdbID string `bson:"imdb_id"`
+		Title  string `bson:"title"`
+	}
+	if err := cursor.All(ctx, &titles); err != nil {
+		return nil, err
+	}
+
+	scores := make(map[string]float64)
+	var matched []string
+	for _, t := range titles {
+		score := max(utils.TrigramSimilarity(query, t.Title), utils.EditSimilarity(query, t.Title))
+		if score >= fuzzyMatchThreshold {
+			scores[t.ImdbID] = score
+			matched = append(matched, t.ImdbID)
+		}
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool { return scores[matched[i]] > scores[matched[j]] })
+	if int64(len(matched)) > limit {
+		matched = matched[:limit]
+	}
+	if len(matched) == 0 {
+		return []models.MovieSearchResult{}, nil
+	}
+
+	cursor, err = movieCollection.Find(ctx, append(bson.D{{Key: "imdb_id", Value: bson.D{{Key: "$in", Value: matched}}}}, scope...))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var movies []models.Movie
+	if err := cursor.All(ctx, &movies); err != nil {
+		return nil, err
+	}
+
+	results := make([]models.MovieSearchResult, 0, len(movies))
+	for _, movie := range movies {
+		results = append(results, models.MovieSearchResult{Movie: movie, Score: scores[movie.ImdbID]})
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+
+	return results, nil
+}
+
+// highlightMovie wraps the words matching the query in <mark> tags. The rest
+// of the text is HTML-escaped so the result can be rendered directly.
+func highlightMovie(movie models.Movie, terms []string, fuzzy bool) map[string]string {
+	highlights := make(map[string]string)
+
+	if title, ok := highlightText(movie.Title, terms, fuzzy); ok {
+		highlights["title"] = title
+	}
+	if review, ok := highlightText(snippetAroundMatch(movie.AdminReview, terms, fuzzy), terms, fuzzy); ok {
+		highlights["admin_review"] = review
+	}
+
+	return highlights
+}
+
+func highlightText(text string, terms []string, fuzzy bool) (string, bool) {
+	var b strings.Builder
+	found := false
+	last := 0
+
+	for _, loc := range searchWordPattern.FindAllStringIndex(text, -1) {
+		word := text[loc[0]:loc[1]]
+		if !wordMatchesTerms(word, terms, fuzzy) {
+			continue
+		}
+		found = true
+		b.WriteString(html.EscapeString(text[last:loc[0]]))
+		b.WriteString("<mark>" + html.EscapeString(word) + "</mark>")
+		last = loc[1]
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+
+	return b.String(), found
+}
+
+// snippetAroundMatch trims long text to a window around the first match.
+func snippetAroundMatch(text string, terms []string, fuzzy bool) string {
+	if len(text) <= highlightSnippetLength {
+		return text
+	}
+
+	start := 0
+	for _, loc := range searchWordPattern.FindAllStringIndex(text, -1) {
+		if wordMatchesTerms(text[loc[0]:loc[1]], terms, fuzzy) {
+			start = max(loc[0]-highlightSnippetLength/3, 0)
+			break
+		}
+	}
+	end := min(start+highlightSnippetLength, len(text))
+
+	// Move both ends onto whitespace so no word or rune is cut in half.
+	for start > 0 && text[start-1] != ' ' {
+		start--
+	}
+	for end < len(text) && text[end] != ' ' {
+		end++
+	}
+
+	snippet := strings.TrimSpace(text[start:end])
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(text) {
+		snippet = snippet + "…"
+	}
+	return snippet
+}
+
+// wordMatchesTerms mirrors how the text index matches (case-insensitive,
+// shared stem) and, for fuzzy results, tolerates small typos.
+func wordMatchesTerms(word string, terms []string, fuzzy bool) bool {
+	word = strings.ToLower(word)
+	for _, term := range terms {
+		if word == term {
+			return true
+		}
+		if len(term) >= 4 && len(word) >= 4 && (strings.HasPrefix(word, term) || strings.HasPrefix(term, word)) {
+			return true
+		}
+		if fuzzy && len(term) >= 3 && utils.Levenshtein(word, term) <= max(1, len(term)/4) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GetSessions lists the devices the current user is logged in on.
+func GetSessions(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		sessions, err := utils.ListActiveSessions(ctx, client, c.GetString("userId"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+			return
+		}
+
+		currentId := c.GetString("sessionId")
+		for i := range sessions {
+			sessions[i].Current = sessions[i].SessionID == currentId
+		}
+
+		c.JSON(http.StatusOK, sessions)
+	}
+}
+
+// RevokeSession logs one of the current user's devices out. Its access and
+// refresh tokens stop working immediately.
+func RevokeSession(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		err := utils.RevokeUserSession(ctx, client, c.GetString("userId"), c.Param("session_id"))
+		if err != nil {
+			if err == utils.ErrSessionNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+	}
+}
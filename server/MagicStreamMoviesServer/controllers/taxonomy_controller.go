@@ -0,0 +1,686 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/database"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// notRankedValue is the placeholder ranking for movies without a review. It
+// is managed by the system and cannot be renamed, reordered or deleted.
+const notRankedValue = 999
+
+// =========================
+// GENRES
+// =========================
+
+func CreateGenre(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var req struct {
+			GenreID   int    `json:"genreId"`
+			GenreName string `json:"genreName" validate:"required,min=2,max=100"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		req.GenreName = strings.TrimSpace(req.GenreName)
+		if err := validate.Struct(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		genreCollection := database.OpenCollection("genres", client)
+
+		if taken, err := genreNameTaken(ctx, genreCollection, req.GenreName, -1); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing genres"})
+			return
+		} else if taken {
+			c.JSON(http.StatusConflict, gin.H{"error": "A genre with this name already exists"})
+			return
+		}
+
+		// New genres go to the end of the list, and get the next free id
+		// unless the caller picked one.
+		var last models.Genre
+		maxId, maxPosition := 0, 0
+		if err := genreCollection.FindOne(ctx, bson.D{}, options.FindOne().SetSort(bson.D{{Key: "genre_id", Value: -1}})).Decode(&last); err == nil {
+			maxId = last.GenreID
+		}
+		if err := genreCollection.FindOne(ctx, bson.D{}, options.FindOne().SetSort(bson.D{{Key: "position", Value: -1}})).Decode(&last); err == nil {
+			maxPosition = last.Position
+		}
+
+		genre := models.Genre{GenreID: req.GenreID, GenreName: req.GenreName}
+		if genre.GenreID <= 0 {
+			genre.GenreID = maxId + 1
+		}
+		if maxPosition > 0 {
+			genre.Position = maxPosition + 1
+		}
+
+		if _, err := genreCollection.InsertOne(ctx, genre); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				c.JSON(http.StatusConflict, gin.H{"error": "A genre with this id already exists"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create genre"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, genre)
+	}
+}
+
+// RenameGenre renames a genre and every embedded copy of it in
+// movies.genre and users.favourite_genres.
+func RenameGenre(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		genreId, err := strconv.Atoi(c.Param("genre_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "genre_id must be an integer"})
+			return
+		}
+
+		var req struct {
+			GenreName string `json:"genreName" validate:"required,min=2,max=100"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		req.GenreName = strings.TrimSpace(req.GenreName)
+		if err := validate.Struct(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		genreCollection := database.OpenCollection("genres", client)
+
+		if taken, err := genreNameTaken(ctx, genreCollection, req.GenreName, genreId); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing genres"})
+			return
+		} else if taken {
+			c.JSON(http.StatusConflict, gin.H{"error": "A genre with this name already exists"})
+			return
+		}
+
+		result, err := genreCollection.UpdateOne(ctx,
+			bson.M{"genre_id": genreId},
+			bson.M{"$set": bson.M{"genre_name": req.GenreName}},
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rename genre"})
+			return
+		}
+		if result.MatchedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Genre not found"})
+			return
+		}
+
+		cascaded, err := cascadeGenreRename(ctx, client, genreId, req.GenreName)
+		if err != nil {
+			log.Println("Genre rename cascade failed:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Genre renamed but updating movies and users failed", "details": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"genreId":        genreId,
+			"genreName":      req.GenreName,
+			"movies_updated": cascaded["movies"],
+			"users_updated":  cascaded["users"],
+		})
+	}
+}
+
+// ReorderGenres sets the position of every genre from the order of the ids
+// in the request. All genres must be listed exactly once.
+func ReorderGenres(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var req struct {
+			GenreIDs []int `json:"genreIds" validate:"required,min=1"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		genreCollection := database.OpenCollection("genres", client)
+
+		var genres []models.Genre
+		cursor, err := genreCollection.Find(ctx, bson.D{})
+		if err == nil {
+			err = cursor.All(ctx, &genres)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch genres"})
+			return
+		}
+
+		known := make(map[int]bool, len(genres))
+		for _, g := range genres {
+			known[g.GenreID] = true
+		}
+		seen := make(map[int]bool, len(req.GenreIDs))
+		for _, id := range req.GenreIDs {
+			if !known[id] || seen[id] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "genreIds must list every existing genre exactly once"})
+				return
+			}
+			seen[id] = true
+		}
+		if len(seen) != len(known) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "genreIds must list every existing genre exactly once"})
+			return
+		}
+
+		writes := make([]mongo.WriteModel, 0, len(req.GenreIDs))
+		for i, id := range req.GenreIDs {
+			writes = append(writes, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"genre_id": id}).
+				SetUpdate(bson.M{"$set": bson.M{"position": i + 1}}))
+		}
+		if _, err := genreCollection.BulkWrite(ctx, writes); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder genres"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Genres reordered", "genreIds": req.GenreIDs})
+	}
+}
+
+// DeleteGenre refuses to delete a genre that movies or users still reference
+// unless ?reassign_to=<genre_id> names a genre to move them to.
+func DeleteGenre(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		genreId, err := strconv.Atoi(c.Param("genre_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "genre_id must be an integer"})
+			return
+		}
+
+		genreCollection := database.OpenCollection("genres", client)
+
+		var genre models.Genre
+		if err := genreCollection.FindOne(ctx, bson.M{"genre_id": genreId}).Decode(&genre); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Genre not found"})
+			return
+		}
+
+		usage, err := genreUsage(ctx, client, genreId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check genre usage"})
+			return
+		}
+
+		inUse := false
+		for _, count := range usage {
+			inUse = inUse || count > 0
+		}
+		reassignTo := c.Query("reassign_to")
+
+		if inUse && reassignTo == "" {
+			conflict := gin.H{"error": "Genre is still in use; pass reassign_to=<genre_id> to move references first"}
+			for name, count := range usage {
+				conflict[name] = count
+			}
+			c.JSON(http.StatusConflict, conflict)
+			return
+		}
+
+		var reassigned map[string]int64
+		if inUse {
+			targetId, err := strconv.Atoi(reassignTo)
+			if err != nil || targetId == genreId {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "reassign_to must be the id of another genre"})
+				return
+			}
+
+			var target models.Genre
+			if err := genreCollection.FindOne(ctx, bson.M{"genre_id": targetId}).Decode(&target); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "reassign_to genre not found"})
+				return
+			}
+
+			reassigned, err = reassignGenre(ctx, client, genreId, models.Genre{GenreID: target.GenreID, GenreName: target.GenreName})
+			if err != nil {
+				log.Println("Genre reassignment failed:", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reassign genre references", "details": err.Error()})
+				return
+			}
+		}
+
+		if _, err := genreCollection.DeleteOne(ctx, bson.M{"genre_id": genreId}); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete genre"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":           "Genre deleted",
+			"genreId":           genreId,
+			"movies_reassigned": reassigned["movies"],
+			"users_reassigned":  reassigned["users"],
+		})
+	}
+}
+
+func genreNameTaken(ctx context.Context, genreCollection *mongo.Collection, name string, exceptId int) (bool, error) {
+	count, err := genreCollection.CountDocuments(ctx, bson.M{
+		"genre_name": bson.M{"$regex": "^" + regexp.QuoteMeta(name) + "$", "$options": "i"},
+		"genre_id":   bson.M{"$ne": exceptId},
+	})
+	return count > 0, err
+}
+
+// genreReference is an embedded array of models.Genre. Lists nested in
+// another array, like each household profile's favourites, name that array
+// as parent; updates then apply to every element of it.
+type genreReference struct {
+	name       string
+	collection string
+	field      string
+	parent     string
+}
+
+// genreReferences lists every embedded array of models.Genre. name keys the
+// counts reported by the genre endpoints.
+var genreReferences = []genreReference{
+	{name: "movies", collection: "movies", field: "genre"},
+	{name: "users", collection: "users", field: "favourite_genres"},
+	{name: "profiles", collection: "users", field: "favourite_genres", parent: "profiles"},
+}
+
+// queryPath is the dotted path of the list for use in filters.
+func (r genreReference) queryPath() string {
+	if r.parent == "" {
+		return r.field
+	}
+	return r.parent + "." + r.field
+}
+
+// updatePath is the path of the list for use in updates, with elem standing
+// for the parent element: "$[]" for all of them or "$[p]" for filtered ones.
+func (r genreReference) updatePath(elem string) string {
+	if r.parent == "" {
+		return r.field
+	}
+	return r.parent + "." + elem + "." + r.field
+}
+
+func genreUsage(ctx context.Context, client *mongo.Client, genreId int) (map[string]int64, error) {
+	usage := make(map[string]int64)
+	for _, ref := range genreReferences {
+		count, err := database.OpenCollection(ref.collection, client).CountDocuments(ctx, bson.M{ref.queryPath() + ".genre_id": genreId})
+		if err != nil {
+			return nil, err
+		}
+		usage[ref.name] = count
+	}
+	return usage, nil
+}
+
+func cascadeGenreRename(ctx context.Context, client *mongo.Client, genreId int, name string) (map[string]int64, error) {
+	updated := make(map[string]int64)
+	for _, ref := range genreReferences {
+		result, err := database.OpenCollection(ref.collection, client).UpdateMany(ctx,
+			bson.M{ref.queryPath() + ".genre_id": genreId},
+			bson.M{"$set": bson.M{ref.updatePath("$[]") + ".$[g].genre_name": name}},
+			options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"g.genre_id": genreId}}}),
+		)
+		if err != nil {
+			return updated, err
+		}
+		updated[ref.name] = result.ModifiedCount
+	}
+	return updated, nil
+}
+
+// reassignGenre replaces genreId with target in every embedded genre list.
+// Lists that already contain target just drop genreId so no list ends up
+// with the same genre twice.
+func reassignGenre(ctx context.Context, client *mongo.Client, genreId int, target models.Genre) (map[string]int64, error) {
+	updated := make(map[string]int64)
+	for _, ref := range genreReferences {
+		collection := database.OpenCollection(ref.collection, client)
+		both := bson.M{"$all": bson.A{genreId, target.GenreID}}
+
+		var pulled *mongo.UpdateResult
+		var err error
+		if ref.parent == "" {
+			pulled, err = collection.UpdateMany(ctx,
+				bson.M{ref.field + ".genre_id": both},
+				bson.M{"$pull": bson.M{ref.field: bson.M{"genre_id": genreId}}},
+			)
+		} else {
+			pulled, err = collection.UpdateMany(ctx,
+				bson.M{ref.queryPath() + ".genre_id": genreId},
+				bson.M{"$pull": bson.M{ref.updatePath("$[p]"): bson.M{"genre_id": genreId}}},
+				options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"p." + ref.field + ".genre_id": both}}}),
+			)
+		}
+		if err != nil {
+			return updated, err
+		}
+
+		replaced, err := collection.UpdateMany(ctx,
+			bson.M{ref.queryPath() + ".genre_id": genreId},
+			bson.M{"$set": bson.M{ref.updatePath("$[]") + ".$[g]": target}},
+			options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"g.genre_id": genreId}}}),
+		)
+		if err != nil {
+			return updated, err
+		}
+
+		updated[ref.name] = pulled.ModifiedCount + replaced.ModifiedCount
+	}
+	return updated, nil
+}
+
+// =========================
+// RANKINGS
+// =========================
+
+// GetRankingsHandler lists the rankings ordered by ranking_value.
+func GetRankingsHandler(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rankings, err := GetRankings(client, c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching rankings"})
+			return
+		}
+		if rankings == nil {
+			rankings = []models.Ranking{}
+		}
+
+		c.JSON(http.StatusOK, rankings)
+	}
+}
+
+func CreateRanking(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var ranking models.Ranking
+		if err := c.ShouldBindJSON(&ranking); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		ranking.RankingName = strings.TrimSpace(ranking.RankingName)
+		if err := validate.Struct(ranking); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+		if ranking.RankingValue == notRankedValue {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ranking_value 999 is reserved"})
+			return
+		}
+
+		rankingCollection := database.OpenCollection("rankings", client)
+
+		count, err := rankingCollection.CountDocuments(ctx, bson.M{"$or": bson.A{
+			bson.M{"ranking_name": bson.M{"$regex": "^" + regexp.QuoteMeta(ranking.RankingName) + "$", "$options": "i"}},
+			bson.M{"ranking_value": ranking.RankingValue},
+		}})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing rankings"})
+			return
+		}
+		if count > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "A ranking with this name or value already exists"})
+			return
+		}
+
+		if _, err := rankingCollection.InsertOne(ctx, ranking); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				c.JSON(http.StatusConflict, gin.H{"error": "A ranking with this name already exists"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create ranking"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, ranking)
+	}
+}
+
+// RenameRanking renames a ranking and the ranking embedded in every movie
+// that uses it.
+func RenameRanking(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		oldName := c.Param("ranking_name")
+
+		var req struct {
+			RankingName string `json:"ranking_name" validate:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		req.RankingName = strings.TrimSpace(req.RankingName)
+		if err := validate.Struct(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		rankingCollection := database.OpenCollection("rankings", client)
+
+		var existing models.Ranking
+		if err := rankingCollection.FindOne(ctx, bson.M{"ranking_name": oldName}).Decode(&existing); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Ranking not found"})
+			return
+		}
+		if existing.RankingValue == notRankedValue {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "The not-ranked placeholder cannot be renamed"})
+			return
+		}
+
+		count, err := rankingCollection.CountDocuments(ctx, bson.M{
+			"ranking_name":  bson.M{"$regex": "^" + regexp.QuoteMeta(req.RankingName) + "$", "$options": "i"},
+			"ranking_value": bson.M{"$ne": existing.RankingValue},
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing rankings"})
+			return
+		}
+		if count > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "A ranking with this name already exists"})
+			return
+		}
+
+		if _, err := rankingCollection.UpdateOne(ctx,
+			bson.M{"ranking_name": oldName},
+			bson.M{"$set": bson.M{"ranking_name": req.RankingName}},
+		); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rename ranking"})
+			return
+		}
+
+		result, err := database.OpenCollection("movies", client).UpdateMany(ctx,
+			bson.M{"ranking.ranking_name": oldName},
+			bson.M{"$set": bson.M{"ranking.ranking_name": req.RankingName}},
+		)
+		if err != nil {
+			log.Println("Ranking rename cascade failed:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ranking renamed but updating movies failed"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"ranking_name":   req.RankingName,
+			"ranking_value":  existing.RankingValue,
+			"movies_updated": result.ModifiedCount,
+		})
+	}
+}
+
+// ReorderRankings assigns ranking_value 1..n in the order the names are
+// listed (1 is the best) and updates the value stored on every movie.
+func ReorderRankings(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var req struct {
+			RankingNames []string `json:"ranking_names" validate:"required,min=1"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		rankings, err := GetRankings(client, ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rankings"})
+			return
+		}
+
+		known := make(map[string]bool)
+		for _, r := range rankableRankings(rankings) {
+			known[r.RankingName] = true
+		}
+		seen := make(map[string]bool)
+		for _, name := range req.RankingNames {
+			if !known[name] || seen[name] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "ranking_names must list every ranking exactly once"})
+				return
+			}
+			seen[name] = true
+		}
+		if len(seen) != len(known) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ranking_names must list every ranking exactly once"})
+			return
+		}
+
+		rankingCollection := database.OpenCollection("rankings", client)
+		movieCollection := database.OpenCollection("movies", client)
+
+		// Match on names rather than values so swapping two values cannot
+		// collide halfway through.
+		var moviesUpdated int64
+		reordered := make([]models.Ranking, 0, len(req.RankingNames))
+		for i, name := range req.RankingNames {
+			value := i + 1
+			if _, err := rankingCollection.UpdateOne(ctx,
+				bson.M{"ranking_name": name},
+				bson.M{"$set": bson.M{"ranking_value": value}},
+			); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder rankings"})
+				return
+			}
+
+			result, err := movieCollection.UpdateMany(ctx,
+				bson.M{"ranking.ranking_name": name},
+				bson.M{"$set": bson.M{"ranking.ranking_value": value}},
+			)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update movie rankings"})
+				return
+			}
+			moviesUpdated += result.ModifiedCount
+			reordered = append(reordered, models.Ranking{RankingValue: value, RankingName: name})
+		}
+
+		c.JSON(http.StatusOK, gin.H{"rankings": reordered, "movies_updated": moviesUpdated})
+	}
+}
+
+// DeleteRanking refuses to delete a ranking that movies still use unless
+// ?reassign_to=<ranking_name> names a ranking to move them to.
+func DeleteRanking(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		name := c.Param("ranking_name")
+
+		rankingCollection := database.OpenCollection("rankings", client)
+		movieCollection := database.OpenCollection("movies", client)
+
+		var existing models.Ranking
+		if err := rankingCollection.FindOne(ctx, bson.M{"ranking_name": name}).Decode(&existing); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Ranking not found"})
+			return
+		}
+		if existing.RankingValue == notRankedValue {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "The not-ranked placeholder cannot be deleted"})
+			return
+		}
+
+		inUse, err := movieCollection.CountDocuments(ctx, bson.M{"ranking.ranking_name": name})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check ranking usage"})
+			return
+		}
+
+		reassignTo := c.Query("reassign_to")
+		if inUse > 0 && reassignTo == "" {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":  "Ranking is still in use; pass reassign_to=<ranking_name> to move movies first",
+				"movies": inUse,
+			})
+			return
+		}
+
+		var reassigned int64
+		if inUse > 0 {
+			var target models.Ranking
+			if reassignTo == name || rankingCollection.FindOne(ctx, bson.M{"ranking_name": reassignTo}).Decode(&target) != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "reassign_to must be the name of another ranking"})
+				return
+			}
+
+			result, err := movieCollection.UpdateMany(ctx,
+				bson.M{"ranking.ranking_name": name},
+				bson.M{"$set": bson.M{"ranking": target}},
+			)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reassign movies"})
+				return
+			}
+			reassigned = result.ModifiedCount
+		}
+
+		if _, err := rankingCollection.DeleteOne(ctx, bson.M{"ranking_name": name}); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete ranking"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Ranking deleted", "ranking_name": name, "movies_reassigned": reassigned})
+	}
+}
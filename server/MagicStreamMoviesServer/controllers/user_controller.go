@@ -14,24 +14,20 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
-	"golang.org/x/crypto/bcrypt"
 )
 
+// HashPassword hashes a password with the configured algorithm; see
+// utils.HashPassword.
 func HashPassword(password string) (string, error) {
-	HashPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", err
-	}
-
-	return string(HashPassword), nil
-
+	return utils.HashPassword(password)
 }
+
 func RegisterUser(client *mongo.Client) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var user models.User
+		var input models.UserRegister
 
 		// Bind JSON
-		if err := c.ShouldBindJSON(&user); err != nil {
+		if err := c.ShouldBindJSON(&input); err != nil {
 			c.JSON(http.StatusBadRequest, models.APIResponse{
 				Status:    "fail",
 				Error:     true,
@@ -44,7 +40,7 @@ func RegisterUser(client *mongo.Client) gin.HandlerFunc {
 
 		// Validate required fields
 		validate := validator.New()
-		if err := validate.Struct(user); err != nil {
+		if err := validate.Struct(input); err != nil {
 			c.JSON(http.StatusBadRequest, models.APIResponse{
 				Status:    "fail",
 				Error:     true,
@@ -56,7 +52,7 @@ func RegisterUser(client *mongo.Client) gin.HandlerFunc {
 		}
 
 		// Ensure at least one genre is selected
-		if len(user.FavouriteGenres) == 0 {
+		if len(input.FavouriteGenres) == 0 {
 			c.JSON(http.StatusBadRequest, models.APIResponse{
 				Status:    "fail",
 				Error:     true,
@@ -68,7 +64,7 @@ func RegisterUser(client *mongo.Client) gin.HandlerFunc {
 		}
 
 		// Hash password
-		hashedPassword, err := HashPassword(user.Password)
+		hashedPassword, err := HashPassword(input.Password)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.APIResponse{
 				Status:    "error",
@@ -86,7 +82,7 @@ func RegisterUser(client *mongo.Client) gin.HandlerFunc {
 		userCollection := database.OpenCollection("users", client)
 
 		// Check if email exists
-		count, err := userCollection.CountDocuments(ctx, bson.M{"email": user.Email})
+		count, err := userCollection.CountDocuments(ctx, bson.M{"email": input.Email})
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.APIResponse{
 				Status:    "error",
@@ -110,16 +106,32 @@ func RegisterUser(client *mongo.Client) gin.HandlerFunc {
 		}
 
 		// Create user object
-		user.UserID = primitive.NewObjectID().Hex()
-		user.CreatedAt = time.Now()
-		user.UpdatedAt = time.Now()
-		user.Password = hashedPassword
-		user.Token = ""
-		user.RefreshToken = ""
+		user := models.User{
+			UserID:          primitive.NewObjectID().Hex(),
+			FirstName:       input.FirstName,
+			LastName:        input.LastName,
+			Email:           input.Email,
+			Password:        hashedPassword,
+			Role:            "USER",
+			CreatedAt:       time.Now(),
+			UpdatedAt:       time.Now(),
+			FavouriteGenres: input.FavouriteGenres,
+		}
 
 		// Save user
 		_, err = userCollection.InsertOne(ctx, user)
 		if err != nil {
+			// Unique index on email caught a concurrent registration
+			if mongo.IsDuplicateKeyError(err) {
+				c.JSON(http.StatusConflict, models.APIResponse{
+					Status:    "fail",
+					Error:     true,
+					Message:   "User already exists",
+					Content:   gin.H{},
+					Timestamp: time.Now(),
+				})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, models.APIResponse{
 				Status:    "error",
 				Error:     true,
@@ -130,17 +142,10 @@ func RegisterUser(client *mongo.Client) gin.HandlerFunc {
 			return
 		}
 
+		sendAccountEmail(client, user.UserID, user.Email, models.UserTokenEmailVerification)
+
 		// Return standardized response
-		userContent := models.UserContent{
-			UserID:         user.UserID,
-			FirstName:      user.FirstName,
-			LastName:       user.LastName,
-			Email:          user.Email,
-			Role:           user.Role,
-			Token:          nil,
-			RefreshToken:   nil,
-			FavoriteGenres: user.FavouriteGenres,
-		}
+		userContent := models.NewUserContent(&user)
 
 		c.JSON(http.StatusCreated, models.APIResponse{
 			Status:    "success",
@@ -171,12 +176,18 @@ func LoginUser(client *mongo.Client) gin.HandlerFunc {
 		ctx, cancel := context.WithTimeout(c, 100*time.Second)
 		defer cancel()
 
+		if loginThrottled(c, ctx, userLogin.Email) {
+			return
+		}
+
 		userCollection := database.OpenCollection("users", client)
 
 		// Find user by email
 		var foundUser models.User
 		err := userCollection.FindOne(ctx, bson.D{{Key: "email", Value: userLogin.Email}}).Decode(&foundUser)
 		if err != nil {
+			burnPasswordCheck(userLogin.Password)
+			recordLoginFailure(c, ctx, client, userLogin.Email)
 			c.JSON(http.StatusUnauthorized, models.APIResponse{
 				Status:    "error",
 				Error:     true,
@@ -187,7 +198,9 @@ func LoginUser(client *mongo.Client) gin.HandlerFunc {
 		}
 
 		// Compare password
-		if err := bcrypt.CompareHashAndPassword([]byte(foundUser.Password), []byte(userLogin.Password)); err != nil {
+		passwordOk, needsRehash := utils.VerifyPassword(foundUser.Password, userLogin.Password)
+		if !passwordOk {
+			recordLoginFailure(c, ctx, client, userLogin.Email)
 			c.JSON(http.StatusUnauthorized, models.APIResponse{
 				Status:    "error",
 				Error:     true,
@@ -197,67 +210,47 @@ func LoginUser(client *mongo.Client) gin.HandlerFunc {
 			return
 		}
 
-		// Generate JWT tokens
-		token, refreshToken, err := utils.GenerateAllTokens(
-			foundUser.Email,
-			foundUser.FirstName,
-			foundUser.LastName,
-			foundUser.Role,
-			foundUser.UserID,
-		)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, models.APIResponse{
+		resetLoginFailures(ctx, userLogin.Email)
+
+		if needsRehash {
+			upgradePasswordHash(ctx, client, &foundUser, userLogin.Password)
+		}
+
+		if foundUser.Disabled {
+			c.JSON(http.StatusForbidden, models.APIResponse{
 				Status:    "error",
 				Error:     true,
-				Message:   "Failed to generate tokens",
-				Content:   err.Error(),
+				Message:   "This account has been disabled",
 				Timestamp: time.Now(),
 			})
 			return
 		}
 
-		// Update tokens in DB
-		if err := utils.UpdateAllTokens(foundUser.UserID, token, refreshToken, client); err != nil {
-			c.JSON(http.StatusInternalServerError, models.APIResponse{
+		if emailVerificationRequired() && !foundUser.EmailVerified {
+			c.JSON(http.StatusForbidden, models.APIResponse{
 				Status:    "error",
 				Error:     true,
-				Message:   "Failed to update tokens",
-				Content:   err.Error(),
+				Message:   "Please verify your email address before logging in",
+				Content:   gin.H{"emailVerificationRequired": true},
 				Timestamp: time.Now(),
 			})
 			return
 		}
 
-		// Set tokens as HttpOnly cookies (not in JSON)
-		http.SetCookie(c.Writer, &http.Cookie{
-			Name:     "access_token",
-			Value:    token,
-			Path:     "/",
-			MaxAge:   86400, // 1 day
-			Secure:   true,
-			HttpOnly: true,
-			SameSite: http.SameSiteNoneMode,
-		})
-		http.SetCookie(c.Writer, &http.Cookie{
-			Name:     "refresh_token",
-			Value:    refreshToken,
-			Path:     "/",
-			MaxAge:   604800, // 7 days
-			Secure:   true,
-			HttpOnly: true,
-			SameSite: http.SameSiteNoneMode,
-		})
+		// Accounts with two-factor authentication get a short-lived token
+		// that can only be exchanged, together with a code, at /login/mfa.
+		if foundUser.MFAEnabled {
+			respondMFAChallenge(c, &foundUser, utils.TokenUseMFA, "Two-factor code required")
+			return
+		}
+		if utils.MFARequiredForRole(foundUser.Role) {
+			respondMFAChallenge(c, &foundUser, utils.TokenUseMFAEnroll, "Two-factor enrollment required")
+			return
+		}
 
-		// Prepare user content (tokens null in JSON)
-		userContent := models.UserContent{
-			UserID:         foundUser.UserID,
-			FirstName:      foundUser.FirstName,
-			LastName:       foundUser.LastName,
-			Email:          foundUser.Email,
-			Role:           foundUser.Role,
-			Token:          nil, // tokens sent via cookies
-			RefreshToken:   nil,
-			FavoriteGenres: foundUser.FavouriteGenres,
+		userContent, ok := completeLogin(c, ctx, client, &foundUser)
+		if !ok {
+			return
 		}
 
 		// Send standardized JSON response
@@ -271,89 +264,194 @@ func LoginUser(client *mongo.Client) gin.HandlerFunc {
 	}
 }
 
+// completeLogin opens a new session for a user who has passed every login
+// check and hands out the tokens, as cookies or in the body in token mode.
+// On failure it writes the error response and returns false.
+func completeLogin(c *gin.Context, ctx context.Context, client *mongo.Client, foundUser *models.User) (*models.UserContent, bool) {
+	// Generate JWT tokens for a new session on this device
+	sessionId := utils.NewSessionID()
+	token, refreshToken, err := utils.GenerateAllTokens(
+		foundUser.Email,
+		foundUser.FirstName,
+		foundUser.LastName,
+		foundUser.Role,
+		foundUser.UserID,
+		sessionId,
+		"",
+		foundUser.TokenVersion,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Status:    "error",
+			Error:     true,
+			Message:   "Failed to generate tokens",
+			Content:   err.Error(),
+			Timestamp: time.Now(),
+		})
+		return nil, false
+	}
+
+	if err := utils.CreateSession(ctx, client, sessionId, foundUser.UserID, refreshToken, c.Request.UserAgent(), c.ClientIP()); err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Status:    "error",
+			Error:     true,
+			Message:   "Failed to create session",
+			Content:   err.Error(),
+			Timestamp: time.Now(),
+		})
+		return nil, false
+	}
+
+	// Prepare user content (tokens null in JSON)
+	userContent := models.NewUserContent(foundUser)
+
+	if utils.TokenMode(c) {
+		// Non-browser clients get the tokens in the body and no cookies
+		userContent.Token = &token
+		userContent.RefreshToken = &refreshToken
+	} else {
+		setAuthCookies(c, token, refreshToken)
+	}
+
+	return &userContent, true
+}
+
+// LogoutHandler ends the caller's current session. It runs behind
+// AuthMiddleWare, so only the session the access token belongs to is revoked.
 func LogoutHandler(client *mongo.Client) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Clear the access_token cookie
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
 
-		var UserLogout struct {
-			UserId string `json:"user_id"`
+		if sessionId := c.GetString("sessionId"); sessionId != "" {
+			if err := utils.RevokeSession(ctx, client, sessionId, models.SessionRevokedLogout); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error logging out"})
+				return
+			}
 		}
 
-		err := c.ShouldBindJSON(&UserLogout)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
-			return
-		}
+		clearAuthCookies(c)
 
-		fmt.Println("User ID from Logout request:", UserLogout.UserId)
+		c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+	}
+}
 
-		err = utils.UpdateAllTokens(UserLogout.UserId, "", "", client) // Clear tokens in the database
-		// Optionally, you can also remove the user session from the database if needed
+// LogoutAllHandler logs the user out on every device. Bumping the token
+// version invalidates all access and refresh tokens already issued.
+func LogoutAllHandler(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
 
-		if err != nil {
+		userId := c.GetString("userId")
+
+		if err := utils.BumpTokenVersion(ctx, client, userId); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error logging out"})
+			return
+		}
+		if err := utils.RevokeAllSessions(ctx, client, userId, models.SessionRevokedLogoutAll); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error logging out"})
 			return
 		}
-		// c.SetCookie(
-		// 	"access_token",
-		// 	"",
-		// 	-1, // MaxAge negative → delete immediately
-		// 	"/",
-		// 	"localhost", // Adjust to your domain
-		// 	true,        // Use true in production with HTTPS
-		// 	true,        // HttpOnly
-		// )
-		http.SetCookie(c.Writer, &http.Cookie{
-			Name:  "access_token",
-			Value: "",
-			Path:  "/",
-			// Domain:   "localhost",
-			MaxAge:   -1,
-			Secure:   true,
-			HttpOnly: true,
-			SameSite: http.SameSiteNoneMode,
-		})
 
-		// // Clear the refresh_token cookie
-		// c.SetCookie(
-		// 	"refresh_token",
-		// 	"",
-		// 	-1,
-		// 	"/",
-		// 	"localhost",
-		// 	true,
-		// 	true,
-		// )
-		http.SetCookie(c.Writer, &http.Cookie{
-			Name:     "refresh_token",
-			Value:    "",
-			Path:     "/",
-			MaxAge:   -1,
-			Secure:   true,
-			HttpOnly: true,
-			SameSite: http.SameSiteNoneMode,
-		})
+		clearAuthCookies(c)
 
-		c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+		c.JSON(http.StatusOK, gin.H{"message": "Logged out of all devices"})
 	}
 }
 
+// setAuthCookies hands the tokens to browsers as HttpOnly cookies so they
+// are never readable from JavaScript.
+func setAuthCookies(c *gin.Context, token, refreshToken string) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     "access_token",
+		Value:    token,
+		Path:     "/",
+		MaxAge:   86400, // 1 day
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteNoneMode,
+	})
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     "refresh_token",
+		Value:    refreshToken,
+		Path:     "/",
+		MaxAge:   604800, // 7 days
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteNoneMode,
+	})
+}
+
+func clearAuthCookies(c *gin.Context) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     "access_token",
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteNoneMode,
+	})
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     "refresh_token",
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteNoneMode,
+	})
+}
+
 func RefreshTokenHandler(client *mongo.Client) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var ctx, cancel = context.WithTimeout(c, 100*time.Second)
 		defer cancel()
 
-		refreshToken, err := c.Cookie("refresh_token")
-
-		if err != nil {
-			fmt.Println("error", err.Error())
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unable to retrieve refresh token from cookie"})
-			return
+		tokenMode := utils.TokenMode(c)
+
+		// Token-mode clients send the refresh token in the body or as a
+		// bearer token; the cookie is only read in cookie mode so a page
+		// script cannot swap a browser's cookie for tokens in the body.
+		var refreshToken string
+		var err error
+		if tokenMode {
+			var body struct {
+				RefreshToken string `json:"refresh_token"`
+			}
+			if c.Request.ContentLength != 0 {
+				if err := c.ShouldBindJSON(&body); err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+					return
+				}
+			}
+			refreshToken = body.RefreshToken
+			if refreshToken == "" {
+				refreshToken = utils.BearerToken(c)
+			}
+			if refreshToken == "" {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing refresh token"})
+				return
+			}
+		} else {
+			refreshToken, err = c.Cookie("refresh_token")
+
+			if err != nil {
+				fmt.Println("error", err.Error())
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Unable to retrieve refresh token from cookie"})
+				return
+			}
 		}
 
+		// A refresh token that is rejected can never succeed; drop the
+		// cookies so public pages stop sending the dead access token.
 		claim, err := utils.ValidateRefreshToken(refreshToken)
 		if err != nil || claim == nil {
 			fmt.Println("error", err.Error())
+			if !tokenMode {
+				clearAuthCookies(c)
+			}
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
 			return
 		}
@@ -368,10 +466,50 @@ func RefreshTokenHandler(client *mongo.Client) gin.HandlerFunc {
 			return
 		}
 
-		newToken, newRefreshToken, _ := utils.GenerateAllTokens(user.Email, user.FirstName, user.LastName, user.Role, user.UserID)
-		err = utils.UpdateAllTokens(user.UserID, newToken, newRefreshToken, client)
+		// Tokens issued before sessions existed carry no sid and cannot be
+		// rotated; those clients have to log in again.
+		if claim.SessionId == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+			return
+		}
+
+		// Keep the selected profile unless it has been deleted since
+		profileId := claim.ProfileId
+		if profileId != "" && user.FindProfile(profileId) == nil {
+			profileId = ""
+		}
+
+		newToken, newRefreshToken, err := utils.GenerateAllTokens(user.Email, user.FirstName, user.LastName, user.Role, user.UserID, claim.SessionId, profileId, user.TokenVersion)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error generating tokens"})
+			return
+		}
+
+		err = utils.RotateSession(ctx, client, claim.SessionId, refreshToken, newRefreshToken)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating tokens"})
+			switch err {
+			case utils.ErrRefreshTokenReuse:
+				if !tokenMode {
+					clearAuthCookies(c)
+				}
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token reuse detected; the session has been revoked"})
+			case utils.ErrSessionNotFound, utils.ErrSessionRevoked:
+				if !tokenMode {
+					clearAuthCookies(c)
+				}
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Session expired or revoked"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating session"})
+			}
+			return
+		}
+
+		if tokenMode {
+			c.JSON(http.StatusOK, gin.H{
+				"message":       "Tokens refreshed",
+				"token":         newToken,
+				"refresh_token": newRefreshToken,
+			})
 			return
 		}
 
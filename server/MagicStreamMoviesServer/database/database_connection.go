@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -52,7 +53,20 @@ func Connect() *mongo.Client {
 	return client
 }
 
-var Client *mongo.Client = Connect()
+var (
+	sharedClient *mongo.Client
+	sharedOnce   sync.Once
+)
+
+// Shared returns the connection OpenCollection uses. It is made on first use
+// rather than at import, so packages that import database can be tested
+// without a running MongoDB.
+func Shared() *mongo.Client {
+	sharedOnce.Do(func() {
+		sharedClient = Connect()
+	})
+	return sharedClient
+}
 
 func OpenCollection(collectionName string, client *mongo.Client) *mongo.Collection {
 	databaseName := os.Getenv("DATABASE_NAME")
@@ -60,6 +74,6 @@ func OpenCollection(collectionName string, client *mongo.Client) *mongo.Collecti
 		log.Fatal("DATABASE_NAME not set")
 	}
 
-	collection := Client.Database(databaseName).Collection(collectionName)
+	collection := Shared().Database(databaseName).Collection(collectionName)
 	return collection
 }
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// collectionIndexes lists the indexes that must exist before the server starts
+// handling requests, keyed by collection name.
+var collectionIndexes = map[string][]mongo.IndexModel{
+	"movies": {
+		{
+			Keys: bson.D{{Key: "title", Value: "text"}, {Key: "admin_review", Value: "text"}},
+			Options: options.Index().
+				SetName("movies_text").
+				SetWeights(bson.D{{Key: "title", Value: 10}, {Key: "admin_review", Value: 2}}),
+		},
+		{
+			Keys:    bson.D{{Key: "imdb_id", Value: 1}},
+			Options: options.Index().SetName("movies_imdb_id_unique").SetUnique(true),
+		},
+	},
+	"genres": {
+		{
+			Keys:    bson.D{{Key: "genre_id", Value: 1}},
+			Options: options.Index().SetName("genres_genre_id_unique").SetUnique(true),
+		},
+	},
+	"rankings": {
+		{
+			Keys:    bson.D{{Key: "ranking_name", Value: 1}},
+			Options: options.Index().SetName("rankings_ranking_name_unique").SetUnique(true),
+		},
+	},
+	"jobs": {
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "run_at", Value: 1}},
+			Options: options.Index().SetName("jobs_status_run_at"),
+		},
+	},
+	"sessions": {
+		{
+			Keys:    bson.D{{Key: "session_id", Value: 1}},
+			Options: options.Index().SetName("sessions_session_id_unique").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetName("sessions_user_id"),
+		},
+		{
+			// MongoDB deletes sessions once they expire
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetName("sessions_expires_at_ttl").SetExpireAfterSeconds(0),
+		},
+	},
+	"login_attempts": {
+		{
+			Keys:    bson.D{{Key: "key", Value: 1}},
+			Options: options.Index().SetName("login_attempts_key_unique").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetName("login_attempts_expires_at_ttl").SetExpireAfterSeconds(0),
+		},
+	},
+	"auth_audit": {
+		{
+			Keys:    bson.D{{Key: "email", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("auth_audit_email_created_at"),
+		},
+	},
+	"user_tokens": {
+		{
+			Keys:    bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetName("user_tokens_token_hash_unique").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "purpose", Value: 1}},
+			Options: options.Index().SetName("user_tokens_user_id_purpose"),
+		},
+		{
+			// Used and expired tokens are kept for a day for support, then dropped
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetName("user_tokens_expires_at_ttl").SetExpireAfterSeconds(86400),
+		},
+	},
+	"user_tombstones": {
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetName("user_tombstones_user_id_unique").SetUnique(true),
+		},
+	},
+	"users": {
+		{
+			Keys:    bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().SetName("users_email_unique").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetName("users_user_id_unique").SetUnique(true),
+		},
+	},
+}
+
+// EnsureIndexes creates any missing indexes declared in collectionIndexes.
+// CreateMany is idempotent for indexes that already exist with the same spec.
+// Creating a unique index fails if the collection already holds duplicates;
+// those have to be cleaned up by hand before the server will start.
+func EnsureIndexes(client *mongo.Client) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for collectionName, indexes := range collectionIndexes {
+		names, err := OpenCollection(collectionName, client).Indexes().CreateMany(ctx, indexes)
+		if err != nil {
+			return fmt.Errorf("%s: %w", collectionName, err)
+		}
+		log.Println("Indexes ready on", collectionName+":", names)
+	}
+
+	return nil
+}
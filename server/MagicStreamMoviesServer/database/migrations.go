@@ -0,0 +1,32 @@
+package database
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RemoveLegacyUserTokens deletes the plaintext access and refresh tokens
+// older versions stored on each user. Sessions now keep only token hashes.
+func RemoveLegacyUserTokens(client *mongo.Client) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	result, err := OpenCollection("users", client).UpdateMany(ctx,
+		bson.M{"$or": bson.A{
+			bson.M{"token": bson.M{"$exists": true}},
+			bson.M{"refresh_token": bson.M{"$exists": true}},
+		}},
+		bson.M{"$unset": bson.M{"token": "", "refresh_token": ""}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount > 0 {
+		log.Println("Removed stored tokens from", result.ModifiedCount, "users")
+	}
+	return nil
+}
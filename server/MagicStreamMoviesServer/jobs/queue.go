@@ -0,0 +1,299 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/database"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/models"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Handler runs a single job. The returned map is stored as the job's result.
+// Returning an error schedules a retry with exponential backoff, unless the
+// error is wrapped with Permanent or the job is out of attempts.
+type Handler func(ctx context.Context, job *models.Job) (map[string]interface{}, error)
+
+// ErrJobNotFound is returned by Get for unknown job ids.
+var ErrJobNotFound = errors.New("job not found")
+
+type permanentError struct{ err error }
+
+func (p permanentError) Error() string { return p.err.Error() }
+func (p permanentError) Unwrap() error { return p.err }
+
+// Permanent marks err as not worth retrying.
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+const (
+	collectionName = "jobs"
+	baseBackoff    = 5 * time.Second
+	maxBackoff     = 30 * time.Minute
+)
+
+var (
+	handlersMu sync.RWMutex
+	handlers   = make(map[string]Handler)
+)
+
+// Register associates a job type with its handler. It must be called before
+// StartWorkers.
+func Register(jobType string, handler Handler) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	handlers[jobType] = handler
+}
+
+func handlerFor(jobType string) (Handler, bool) {
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+	handler, ok := handlers[jobType]
+	return handler, ok
+}
+
+// Enqueue stores a new pending job that may run immediately.
+func Enqueue(ctx context.Context, client *mongo.Client, jobType string, payload map[string]interface{}) (*models.Job, error) {
+	return EnqueueAt(ctx, client, jobType, payload, time.Now())
+}
+
+// EnqueueAt stores a new pending job that will not run before runAt.
+func EnqueueAt(ctx context.Context, client *mongo.Client, jobType string, payload map[string]interface{}, runAt time.Time) (*models.Job, error) {
+	return EnqueueWithID(ctx, client, primitive.NewObjectID(), jobType, payload, runAt)
+}
+
+// EnqueueWithID is EnqueueAt with an id chosen by the caller. It lets the
+// caller record the id on the documents the job will look for before any
+// worker can pick the job up.
+func EnqueueWithID(ctx context.Context, client *mongo.Client, id primitive.ObjectID, jobType string, payload map[string]interface{}, runAt time.Time) (*models.Job, error) {
+	now := time.Now()
+	job := &models.Job{
+		ID:          id,
+		Type:        jobType,
+		Status:      models.JobPending,
+		Payload:     payload,
+		MaxAttempts: utils.EnvInt("JOB_MAX_ATTEMPTS", 5),
+		RunAt:       runAt,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if _, err := database.OpenCollection(collectionName, client).InsertOne(ctx, job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// Get loads a job by its hex id.
+func Get(ctx context.Context, client *mongo.Client, jobId string) (*models.Job, error) {
+	id, err := primitive.ObjectIDFromHex(jobId)
+	if err != nil {
+		return nil, ErrJobNotFound
+	}
+
+	var job models.Job
+	err = database.OpenCollection(collectionName, client).FindOne(ctx, bson.M{"_id": id}).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// StartWorkers launches the worker pool. The pool size comes from JOB_WORKERS
+// (default 2), the lease length from JOB_LEASE_SECONDS (default 120) and the
+// idle polling interval from JOB_POLL_SECONDS (default 2). Workers stop when
+// ctx is cancelled.
+func StartWorkers(ctx context.Context, client *mongo.Client) {
+	workers := utils.EnvInt("JOB_WORKERS", 2)
+	lease := time.Duration(utils.EnvInt("JOB_LEASE_SECONDS", 120)) * time.Second
+	poll := time.Duration(utils.EnvInt("JOB_POLL_SECONDS", 2)) * time.Second
+
+	hostname, _ := os.Hostname()
+	for i := 0; i < workers; i++ {
+		w := &worker{
+			id:     fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), i),
+			client: client,
+			lease:  lease,
+			poll:   poll,
+		}
+		go w.run(ctx)
+	}
+
+	log.Println("Started", workers, "job workers")
+}
+
+type worker struct {
+	id     string
+	client *mongo.Client
+	lease  time.Duration
+	poll   time.Duration
+}
+
+func (w *worker) run(ctx context.Context) {
+	for {
+		job, err := w.claim(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Println("Job worker", w.id, "failed to claim job:", err)
+		}
+
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(w.poll):
+			}
+			continue
+		}
+
+		w.execute(ctx, job)
+	}
+}
+
+// claim atomically leases the oldest runnable job: either a pending job
+// whose run_at has passed, or a running job whose lease has expired because
+// its worker died.
+func (w *worker) claim(ctx context.Context) (*models.Job, error) {
+	now := time.Now()
+	leaseUntil := now.Add(w.lease)
+
+	filter := bson.M{"$or": bson.A{
+		bson.M{"status": models.JobPending, "run_at": bson.M{"$lte": now}},
+		bson.M{"status": models.JobRunning, "lease_until": bson.M{"$lt": now}},
+	}}
+	update := bson.M{
+		"$set": bson.M{
+			"status":      models.JobRunning,
+			"lease_owner": w.id,
+			"lease_until": leaseUntil,
+			"updated_at":  now,
+		},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "run_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var job models.Job
+	err := database.OpenCollection(collectionName, w.client).FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (w *worker) execute(ctx context.Context, job *models.Job) {
+	handler, ok := handlerFor(job.Type)
+	if !ok {
+		w.finish(ctx, job, nil, Permanent(fmt.Errorf("no handler registered for job type %q", job.Type)))
+		return
+	}
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Keep extending the lease while the handler runs so long jobs are not
+	// stolen by another worker.
+	go w.heartbeat(jobCtx, job)
+
+	result, err := runHandler(jobCtx, handler, job)
+	w.finish(ctx, job, result, err)
+}
+
+func runHandler(ctx context.Context, handler Handler, job *models.Job) (result map[string]interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+func (w *worker) heartbeat(ctx context.Context, job *models.Job) {
+	ticker := time.NewTicker(w.lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, err := database.OpenCollection(collectionName, w.client).UpdateOne(ctx,
+				bson.M{"_id": job.ID, "lease_owner": w.id, "status": models.JobRunning},
+				bson.M{"$set": bson.M{"lease_until": time.Now().Add(w.lease), "updated_at": time.Now()}},
+			)
+			if err != nil && ctx.Err() == nil {
+				log.Println("Failed to extend lease on job", job.ID.Hex(), err)
+			}
+		}
+	}
+}
+
+// finish records the outcome of a job. Failures are rescheduled with
+// exponential backoff and jitter until MaxAttempts is reached.
+func (w *worker) finish(ctx context.Context, job *models.Job, result map[string]interface{}, jobErr error) {
+	now := time.Now()
+	set := bson.M{"updated_at": now}
+
+	switch {
+	case jobErr == nil:
+		set["status"] = models.JobSucceeded
+		set["result"] = result
+		set["completed_at"] = now
+	case errors.As(jobErr, new(permanentError)) || job.Attempts >= job.MaxAttempts:
+		set["status"] = models.JobFailed
+		set["last_error"] = jobErr.Error()
+		set["completed_at"] = now
+		log.Println("Job", job.ID.Hex(), job.Type, "failed:", jobErr)
+	default:
+		set["status"] = models.JobPending
+		set["last_error"] = jobErr.Error()
+		set["run_at"] = now.Add(Backoff(job.Attempts))
+		log.Println("Job", job.ID.Hex(), job.Type, "attempt", job.Attempts, "failed, retrying:", jobErr)
+	}
+
+	// Only the current lease holder may record the outcome.
+	_, err := database.OpenCollection(collectionName, w.client).UpdateOne(ctx,
+		bson.M{"_id": job.ID, "lease_owner": w.id},
+		bson.M{"$set": set, "$unset": bson.M{"lease_owner": "", "lease_until": ""}},
+	)
+	if err != nil {
+		log.Println("Failed to record outcome of job", job.ID.Hex(), err)
+	}
+}
+
+// Backoff returns the delay before retry number attempt: 5s, 10s, 20s, ...
+// capped at 30 minutes, with up to 20% random jitter.
+func Backoff(attempt int) time.Duration {
+	delay := maxBackoff
+	if attempt < 20 {
+		delay = min(baseBackoff<<max(attempt-1, 0), maxBackoff)
+	}
+	jitter := time.Duration(rand.Int63n(int64(delay)/5 + 1))
+	return delay + jitter
+}
+
+// IsLastAttempt reports whether a failure of the current run will mark the
+// job as failed rather than retry it.
+func IsLastAttempt(job *models.Job) bool {
+	return job.Attempts >= job.MaxAttempts
+}
@@ -0,0 +1,69 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileMailer appends every message to a file instead of sending it, so
+// reset and verification links can be picked up during development.
+type FileMailer struct {
+	mu   sync.Mutex
+	path string
+	from string
+}
+
+func NewFileMailer(path, from string) *FileMailer {
+	return &FileMailer{path: path, from: from}
+}
+
+func (f *FileMailer) Name() string {
+	return BackendFile
+}
+
+func (f *FileMailer) Send(ctx context.Context, msg Message) error {
+	if err := validHeader(msg.To, msg.Subject); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "=== %s ===\n%s\n\n", time.Now().Format(time.RFC3339), format(f.from, msg))
+	return err
+}
+
+// LogMailer writes messages to the server log. It is the default so a
+// fresh checkout works without any mail configuration; the body is only
+// logged when asked for with MAILER=log.
+type LogMailer struct {
+	from     string
+	withBody bool
+}
+
+func NewLogMailer(from string, withBody bool) *LogMailer {
+	return &LogMailer{from: from, withBody: withBody}
+}
+
+func (l *LogMailer) Name() string {
+	return BackendLog
+}
+
+func (l *LogMailer) Send(ctx context.Context, msg Message) error {
+	if !l.withBody {
+		log.Printf("Mail to %s: %s (body not logged)", msg.To, msg.Subject)
+		return nil
+	}
+	log.Printf("Mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
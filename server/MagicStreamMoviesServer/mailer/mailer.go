@@ -0,0 +1,107 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers emails.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+	Name() string
+}
+
+// Supported values for MAILER
+const (
+	BackendSMTP = "smtp"
+	BackendFile = "file"
+	BackendLog  = "log"
+)
+
+var (
+	defaultMailer Mailer
+	defaultErr    error
+	defaultOnce   sync.Once
+)
+
+// Default returns the mailer selected by MAILER. The environment is read
+// once, the first time Default is called.
+func Default() (Mailer, error) {
+	defaultOnce.Do(func() {
+		defaultMailer, defaultErr = NewFromEnv()
+		if defaultErr == nil {
+			log.Println("Mailer:", defaultMailer.Name())
+		}
+	})
+	return defaultMailer, defaultErr
+}
+
+// NewFromEnv builds a mailer from MAILER: "smtp" sends through SMTP_HOST,
+// "file" appends messages to MAIL_FILE for local testing, and "log" writes
+// them to the server log. Without MAILER only the recipient and subject are
+// logged, since bodies carry live reset and verification links.
+func NewFromEnv() (Mailer, error) {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "MagicStream <no-reply@magicstream.local>"
+	}
+
+	switch backend := strings.ToLower(strings.TrimSpace(os.Getenv("MAILER"))); backend {
+	case BackendSMTP:
+		host := os.Getenv("SMTP_HOST")
+		if host == "" {
+			return nil, fmt.Errorf("SMTP_HOST must be set for the smtp mailer")
+		}
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		return NewSMTPMailer(host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), from), nil
+	case BackendFile:
+		path := os.Getenv("MAIL_FILE")
+		if path == "" {
+			path = "mail.log"
+		}
+		return NewFileMailer(path, from), nil
+	case BackendLog:
+		return NewLogMailer(from, true), nil
+	case "":
+		log.Println("Warning: MAILER is not set; emails are not sent and only their recipients are logged")
+		return NewLogMailer(from, false), nil
+	default:
+		return nil, fmt.Errorf("unknown MAILER %q", backend)
+	}
+}
+
+// format renders msg as an RFC 5322 message.
+func format(from string, msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// validHeader rejects values that could inject extra headers.
+func validHeader(values ...string) error {
+	for _, v := range values {
+		if strings.ContainsAny(v, "\r\n") {
+			return fmt.Errorf("invalid header value %q", v)
+		}
+	}
+	return nil
+}
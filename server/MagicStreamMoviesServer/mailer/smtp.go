@@ -0,0 +1,62 @@
+package mailer
+
+import (
+	"context"
+	"net"
+	"net/mail"
+	"net/smtp"
+)
+
+// SMTPMailer sends through an SMTP server, using STARTTLS when the server
+// offers it and PLAIN auth when a username is set.
+type SMTPMailer struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+}
+
+func NewSMTPMailer(host, port, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{
+		addr:     net.JoinHostPort(host, port),
+		host:     host,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+func (s *SMTPMailer) Name() string {
+	return BackendSMTP
+}
+
+func (s *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if err := validHeader(msg.To, msg.Subject); err != nil {
+		return err
+	}
+
+	sender, err := mail.ParseAddress(s.from)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.username != "" {
+		auth = smtp.PlainAuth("", s.username, s.password, s.host)
+	}
+
+	// net/smtp has no context support; run it aside so a cancelled request
+	// does not wait for a slow server.
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(s.addr, auth, sender.Address, []string{msg.To}, format(s.from, msg))
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
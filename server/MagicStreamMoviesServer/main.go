@@ -8,8 +8,13 @@ import (
 	"strings"
 	"time"
 
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/classifier"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/controllers"
 	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/database"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/jobs"
 	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/routes"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/throttle"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/utils"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -19,6 +24,11 @@ import (
 func main() {
 	// This is the main function
 
+	if len(os.Args) > 1 {
+		runCommand(os.Args[1:])
+		return
+	}
+
 	router := gin.Default()
 	router.GET("/hello", func(c *gin.Context) {
 		c.String(200, "Hello, MagicStreamMovies!")
@@ -29,6 +39,10 @@ func main() {
 		log.Println("Warning: unable to find .env file")
 	}
 
+	if err := utils.LoadSigningKeys(); err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+
 	allowedOrigins := os.Getenv("ALLOWED_ORIGINS")
 
 	var origins []string
@@ -43,6 +57,19 @@ func main() {
 		log.Println("Allowed Origin: http://localhost:5173")
 	}
 
+	// ClientIP feeds the login throttle and session records, so forwarded
+	// headers are only believed from the proxies listed in TRUSTED_PROXIES.
+	var trustedProxies []string
+	if raw := os.Getenv("TRUSTED_PROXIES"); raw != "" {
+		for _, proxy := range strings.Split(raw, ",") {
+			trustedProxies = append(trustedProxies, strings.TrimSpace(proxy))
+		}
+	}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+	log.Println("Trusted proxies:", trustedProxies)
+
 	config := cors.Config{}
 	config.AllowOrigins = origins
 	config.AllowMethods = []string{"GET", "POST", "PATCH", "PUT", "DELETE", "OPTIONS"}
@@ -68,6 +95,31 @@ func main() {
 
 	}()
 
+	if err := database.EnsureIndexes(client); err != nil {
+		log.Fatalf("Failed to create indexes: %v", err)
+	}
+
+	if err := database.RemoveLegacyUserTokens(client); err != nil {
+		log.Fatalf("Failed to remove stored user tokens: %v", err)
+	}
+
+	if err := throttle.Configure(client); err != nil {
+		log.Fatalf("Failed to configure login throttling: %v", err)
+	}
+
+	// Build the sentiment classifier up front so a bad SENTIMENT_BACKEND
+	// configuration shows up at startup rather than on the first review.
+	if _, err := classifier.Default(); err != nil {
+		log.Println("Warning: sentiment classifier unavailable:", err)
+	}
+
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	controllers.RegisterJobs(client)
+	jobs.StartWorkers(workerCtx, client)
+	utils.StartKeyRotation(workerCtx)
+
 	routes.SetupUnProtectedRoutes(router, client)
 	routes.SetupProtectedRoutes(router, client)
 
@@ -23,6 +23,11 @@ func AuthMiddleWare() gin.HandlerFunc {
 		}
 		claims, err := utils.ValidateToken(token)
 
+		if err == utils.ErrAccountDisabled {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Account disabled"})
+			c.Abort()
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			c.Abort()
@@ -30,6 +35,8 @@ func AuthMiddleWare() gin.HandlerFunc {
 		}
 		c.Set("userId", claims.UserId)
 		c.Set("role", claims.Role)
+		c.Set("sessionId", claims.SessionId)
+		c.Set("profileId", claims.ProfileId)
 
 		c.Next()
 
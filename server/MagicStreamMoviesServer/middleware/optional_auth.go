@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// OptionalAuthMiddleWare is AuthMiddleWare for public routes that tailor
+// their response to a logged-in caller. A valid token fills the same context
+// keys and a missing one leaves the request anonymous. A token that is sent
+// but fails validation is rejected like in AuthMiddleWare, so an expired
+// session refreshes instead of silently losing its maturity limit.
+func OptionalAuthMiddleWare() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, err := utils.GetAccessToken(c)
+		if err != nil || token == "" {
+			c.Next()
+			return
+		}
+
+		claims, err := utils.ValidateToken(token)
+		if err == utils.ErrAccountDisabled {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Account disabled"})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			c.Abort()
+			return
+		}
+		c.Set("userId", claims.UserId)
+		c.Set("role", claims.Role)
+		c.Set("sessionId", claims.SessionId)
+		c.Set("profileId", claims.ProfileId)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission aborts with 403 unless the caller's role grants perm. It
+// must run after AuthMiddleWare, which puts the role in the context.
+func RequirePermission(perm string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+
+		if !utils.HasPermission(role, perm) {
+			log.Println("Role", role, "lacks permission", perm, "for", c.FullPath())
+			c.JSON(http.StatusForbidden, gin.H{"error": "Missing permission " + perm})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Actions recorded in the movie_audit collection
+const (
+	MovieAuditCreate  = "create"
+	MovieAuditUpdate  = "update"
+	MovieAuditReview  = "review"
+	MovieAuditRank    = "rank"
+	MovieAuditDelete  = "delete"
+	MovieAuditRestore = "restore"
+)
+
+// MovieAudit stores the full movie document before and after a change.
+// Before is nil for creations.
+type MovieAudit struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	ImdbID    string             `bson:"imdb_id" json:"imdb_id"`
+	Action    string             `bson:"action" json:"action"`
+	ActorID   string             `bson:"actor_id" json:"actor_id"`
+	Before    *Movie             `bson:"before,omitempty" json:"before,omitempty"`
+	After     *Movie             `bson:"after,omitempty" json:"after,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// Events recorded in the auth_audit collection
+const (
+	AuthAuditLockout     = "lockout"
+	AuthAuditUnlock      = "unlock"
+	AuthAuditRoleChange  = "role_change"
+	AuthAuditDisable     = "disable"
+	AuthAuditEnable      = "enable"
+	AuthAuditForceLogout = "force_logout"
+)
+
+// AuthAudit records security events on accounts. Key is the throttled key
+// ("email:..." or "ip:...") or, for account changes, "user:<user_id>";
+// ActorID is set for events an admin caused.
+type AuthAudit struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	Event     string             `bson:"event" json:"event"`
+	Key       string             `bson:"key" json:"key"`
+	Email     string             `bson:"email,omitempty" json:"email,omitempty"`
+	IPAddress string             `bson:"ip_address,omitempty" json:"ip_address,omitempty"`
+	ActorID   string             `bson:"actor_id,omitempty" json:"actor_id,omitempty"`
+	Until     *time.Time         `bson:"until,omitempty" json:"until,omitempty"`
+	Detail    string             `bson:"detail,omitempty" json:"detail,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
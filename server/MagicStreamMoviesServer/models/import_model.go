@@ -0,0 +1,27 @@
+package models
+
+// Statuses reported for each row of a bulk movie import
+const (
+	ImportCreated  = "created"
+	ImportUpdated  = "updated"
+	ImportRejected = "rejected"
+)
+
+// ImportRowResult describes what happened (or, in a dry run, would happen)
+// to a single line of an import file.
+type ImportRowResult struct {
+	Line   int    `json:"line"`
+	ImdbID string `json:"imdb_id,omitempty"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// ImportReport summarises a bulk movie import
+type ImportReport struct {
+	DryRun   bool              `json:"dry_run"`
+	Format   string            `json:"format"`
+	Created  int               `json:"created"`
+	Updated  int               `json:"updated"`
+	Rejected int               `json:"rejected"`
+	Rows     []ImportRowResult `json:"rows"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Job statuses
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// Job is a unit of background work stored in the jobs collection. Workers
+// claim a job by taking a time-limited lease on it; a job whose lease has
+// expired is picked up again by another worker.
+type Job struct {
+	ID          primitive.ObjectID     `bson:"_id,omitempty" json:"job_id"`
+	Type        string                 `bson:"type" json:"type"`
+	Status      string                 `bson:"status" json:"status"`
+	Payload     map[string]interface{} `bson:"payload" json:"payload,omitempty"`
+	Result      map[string]interface{} `bson:"result,omitempty" json:"result,omitempty"`
+	Attempts    int                    `bson:"attempts" json:"attempts"`
+	MaxAttempts int                    `bson:"max_attempts" json:"max_attempts"`
+	LastError   string                 `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	RunAt       time.Time              `bson:"run_at" json:"run_at"`
+	LeaseOwner  string                 `bson:"lease_owner,omitempty" json:"-"`
+	LeaseUntil  *time.Time             `bson:"lease_until,omitempty" json:"-"`
+	CreatedAt   time.Time              `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time              `bson:"updated_at" json:"updated_at"`
+	CompletedAt *time.Time             `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+}
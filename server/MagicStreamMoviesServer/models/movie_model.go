@@ -1,10 +1,16 @@
 package models
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type Genre struct {
 	GenreID   int    `bson:"genre_id" json:"genreId"`
 	GenreName string `bson:"genre_name" json:"genreName"`
+	// Position orders the genres collection; it is not set on embedded copies
+	Position int `bson:"position,omitempty" json:"position,omitempty"`
 }
 
 type Ranking struct {
@@ -13,12 +19,50 @@ type Ranking struct {
 }
 
 type Movie struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
-	ImdbID      string             `bson:"imdb_id" json:"imdb_id" validate:"required"`
-	Title       string             `bson:"title" json:"title" validate:"required,min=2,max=500"`
-	PosterPath  string             `bson:"poster_path" json:"poster_path" validate:"required,url"`
-	YouTubeID   string             `bson:"youtube_id" json:"youtube_id" validate:"required"`
-	Genre       []Genre            `bson:"genre" json:"genre" validate:"required,dive"`
-	AdminReview string             `bson:"admin_review" json:"admin_review"`
-	Ranking     Ranking            `bson:"ranking" json:"ranking" validate:"required"`
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	ImdbID        string             `bson:"imdb_id" json:"imdb_id" validate:"required"`
+	Title         string             `bson:"title" json:"title" validate:"required,min=2,max=500"`
+	PosterPath    string             `bson:"poster_path" json:"poster_path" validate:"required,url"`
+	YouTubeID     string             `bson:"youtube_id" json:"youtube_id" validate:"required"`
+	Genre         []Genre            `bson:"genre" json:"genre" validate:"required,dive"`
+	AdminReview   string             `bson:"admin_review" json:"admin_review"`
+	Ranking       Ranking            `bson:"ranking" json:"ranking" validate:"required"`
+	Certification string             `bson:"certification,omitempty" json:"certification,omitempty"`
+	MaturityAge   *int               `bson:"maturity_age,omitempty" json:"maturity_age,omitempty"`
+	RankingStatus string             `bson:"ranking_status,omitempty" json:"ranking_status,omitempty"`
+	RankingJobID  string             `bson:"ranking_job_id,omitempty" json:"-"`
+	DeletedAt     *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+}
+
+// Values of Movie.RankingStatus
+const (
+	RankingStatusPending = "pending"
+	RankingStatusRanked  = "ranked"
+	RankingStatusFailed  = "failed"
+)
+
+// MoviePage is the paginated envelope returned by GET /movies
+type MoviePage struct {
+	Movies     []Movie `json:"movies"`
+	Total      int64   `json:"total"`
+	Page       int64   `json:"page"`
+	PageSize   int64   `json:"page_size"`
+	TotalPages int64   `json:"total_pages"`
+	Next       string  `json:"next,omitempty"`
+	Prev       string  `json:"prev,omitempty"`
+}
+
+// MovieSearchResult is a single hit returned by GET /movies/search
+type MovieSearchResult struct {
+	Movie      Movie             `json:"movie"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+// MovieSearchResponse wraps search hits. MatchType is "text" when the
+// MongoDB text index matched and "fuzzy" when the typo-tolerant fallback ran.
+type MovieSearchResponse struct {
+	Query     string              `json:"query"`
+	MatchType string              `json:"match_type"`
+	Results   []MovieSearchResult `json:"results"`
 }
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UserExport is everything stored about one user, as returned by
+// GET /me/export.
+type UserExport struct {
+	ExportedAt     time.Time    `json:"exported_at"`
+	Profile        UserSummary  `json:"profile"`
+	Sessions       []Session    `json:"sessions"`
+	Reviews        []MovieAudit `json:"reviews"`
+	SecurityEvents []AuthAudit  `json:"security_events"`
+}
+
+// UserTombstone is what remains of a deleted account. It keeps the user id
+// resolvable so movie_audit and auth_audit entries that mention it still
+// make sense.
+type UserTombstone struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	UserID      string             `bson:"user_id" json:"user_id"`
+	Role        string             `bson:"role" json:"role"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	RequestedAt *time.Time         `bson:"requested_at,omitempty" json:"requested_at,omitempty"`
+	DeletedAt   time.Time          `bson:"deleted_at" json:"deleted_at"`
+}
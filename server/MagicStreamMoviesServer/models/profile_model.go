@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Profile is one member of a household sharing an account. Profiles are
+// embedded in the user document; the selected one travels in the "pid"
+// claim of the access token.
+type Profile struct {
+	ProfileID       string    `bson:"profile_id" json:"profileId"`
+	Name            string    `bson:"name" json:"name"`
+	Avatar          string    `bson:"avatar,omitempty" json:"avatar,omitempty"`
+	FavouriteGenres []Genre   `bson:"favourite_genres" json:"favoriteGenres"`
+	Kids            bool      `bson:"kids" json:"kids"`
+	MaxMaturity     *int      `bson:"max_maturity,omitempty" json:"maxMaturity,omitempty"`
+	CreatedAt       time.Time `bson:"created_at" json:"createdAt"`
+}
+
+// ProfileInput is the body for creating a profile, and for PATCH where
+// fields left out are not changed. MaxMaturity is a certification such as
+// "PG-13", or "" for no limit. PIN is the account's parental PIN, needed
+// when one is set to create profiles or change their restrictions.
+type ProfileInput struct {
+	Name            *string  `json:"name" validate:"omitempty,min=1,max=50"`
+	Avatar          *string  `json:"avatar" validate:"omitempty,max=200"`
+	FavouriteGenres *[]Genre `json:"favoriteGenres" validate:"omitempty,min=1"`
+	Kids            *bool    `json:"kids"`
+	MaxMaturity     *string  `json:"maxMaturity"`
+	PIN             string   `json:"pin"`
+}
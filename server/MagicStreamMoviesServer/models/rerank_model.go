@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Re-rank run statuses
+const (
+	RerankPending   = "pending"
+	RerankRunning   = "running"
+	RerankCompleted = "completed"
+	RerankFailed    = "failed"
+)
+
+// RankingChange is one entry of a re-rank diff.
+type RankingChange struct {
+	ImdbID string  `bson:"imdb_id" json:"imdb_id"`
+	Title  string  `bson:"title" json:"title"`
+	Before Ranking `bson:"before" json:"before"`
+	After  Ranking `bson:"after" json:"after"`
+}
+
+// RerankError records a movie that could not be re-classified.
+type RerankError struct {
+	ImdbID string `bson:"imdb_id" json:"imdb_id"`
+	Error  string `bson:"error" json:"error"`
+}
+
+// RerankRun tracks a catalog-wide re-classification. Movies are processed in
+// imdb_id order and Checkpoint holds the last imdb_id of the last completed
+// batch, so an interrupted run picks up where it stopped.
+type RerankRun struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"run_id"`
+	Status      string             `bson:"status" json:"status"`
+	JobID       string             `bson:"job_id,omitempty" json:"job_id,omitempty"`
+	Concurrency int                `bson:"concurrency" json:"concurrency"`
+	RatePerSec  float64            `bson:"rate_per_sec" json:"rate_per_sec"`
+	Checkpoint  string             `bson:"checkpoint" json:"checkpoint,omitempty"`
+	Total       int64              `bson:"total" json:"total"`
+	Processed   int64              `bson:"processed" json:"processed"`
+	Unchanged   int64              `bson:"unchanged" json:"unchanged"`
+	Changes     []RankingChange    `bson:"changes" json:"changes"`
+	Errors      []RerankError      `bson:"errors" json:"errors"`
+	LastError   string             `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	StartedAt   time.Time          `bson:"started_at" json:"started_at"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+	CompletedAt *time.Time         `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+}
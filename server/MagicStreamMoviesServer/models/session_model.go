@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Session is one logged-in device. It holds the hash of the only refresh
+// token that may currently be exchanged for new tokens; every refresh
+// replaces it. All refresh tokens issued for a session carry its SessionID in
+// the "sid" claim, so they form one token family.
+type Session struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	SessionID        string             `bson:"session_id" json:"session_id"`
+	UserID           string             `bson:"user_id" json:"user_id"`
+	RefreshTokenHash string             `bson:"refresh_token_hash" json:"-"`
+	UserAgent        string             `bson:"user_agent,omitempty" json:"user_agent,omitempty"`
+	IPAddress        string             `bson:"ip_address,omitempty" json:"ip_address,omitempty"`
+	CreatedAt        time.Time          `bson:"created_at" json:"created_at"`
+	LastUsedAt       time.Time          `bson:"last_used_at" json:"last_used_at"`
+	ExpiresAt        time.Time          `bson:"expires_at" json:"expires_at"`
+	RevokedAt        *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	RevokedReason    string             `bson:"revoked_reason,omitempty" json:"revoked_reason,omitempty"`
+	Current          bool               `bson:"-" json:"current,omitempty"`
+}
+
+// Values of Session.RevokedReason
+const (
+	SessionRevokedLogout         = "logout"
+	SessionRevokedLogoutAll      = "logout_all"
+	SessionRevokedReuse          = "refresh_token_reuse"
+	SessionRevokedByUser         = "revoked_by_user"
+	SessionRevokedPasswordReset  = "password_reset"
+	SessionRevokedPasswordChange = "password_change"
+	SessionRevokedByAdmin        = "revoked_by_admin"
+)
@@ -6,20 +6,58 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// User represents a MongoDB user document
+// User is the users collection document. It is never rendered directly:
+// responses go through UserContent, so the password hash and MFA secrets
+// cannot leak into JSON.
 type User struct {
-	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID          string             `bson:"user_id" json:"userId"`
-	FirstName       string             `bson:"first_name" json:"firstName" validate:"required,min=2,max=100"`
-	LastName        string             `bson:"last_name" json:"lastName" validate:"required,min=2,max=100"`
-	Email           string             `bson:"email" json:"email" validate:"required,email"`
-	Password        string             `bson:"password" json:"password" validate:"required,min=8,max=20"`
-	Role            string             `bson:"role" json:"role" validate:"oneof=ADMIN USER"`
-	CreatedAt       time.Time          `bson:"created_at" json:"createdAt"`
-	UpdatedAt       time.Time          `bson:"updated_at" json:"updatedAt"`
-	Token           string             `bson:"token" json:"token"`
-	RefreshToken    string             `bson:"refresh_token" json:"refreshToken"`
-	FavouriteGenres []Genre            `bson:"favourite_genres" json:"favoriteGenres" validate:"required,dive"`
+	ID                   primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	UserID               string             `bson:"user_id" json:"userId"`
+	FirstName            string             `bson:"first_name" json:"firstName"`
+	LastName             string             `bson:"last_name" json:"lastName"`
+	Email                string             `bson:"email" json:"email"`
+	EmailVerified        bool               `bson:"email_verified" json:"emailVerified"`
+	PendingEmail         string             `bson:"pending_email,omitempty" json:"-"`
+	Password             string             `bson:"password" json:"-"`
+	Role                 string             `bson:"role" json:"role"`
+	CreatedAt            time.Time          `bson:"created_at" json:"createdAt"`
+	UpdatedAt            time.Time          `bson:"updated_at" json:"updatedAt"`
+	TokenVersion         int                `bson:"token_version" json:"-"`
+	Disabled             bool               `bson:"disabled,omitempty" json:"disabled"`
+	DisabledAt           *time.Time         `bson:"disabled_at,omitempty" json:"disabledAt,omitempty"`
+	DisabledReason       string             `bson:"disabled_reason,omitempty" json:"disabledReason,omitempty"`
+	DeletionRequestedAt  *time.Time         `bson:"deletion_requested_at,omitempty" json:"-"`
+	DeletionScheduledFor *time.Time         `bson:"deletion_scheduled_for,omitempty" json:"deletionScheduledFor,omitempty"`
+	DeletionJobID        string             `bson:"deletion_job_id,omitempty" json:"-"`
+	MFAEnabled           bool               `bson:"mfa_enabled" json:"mfaEnabled"`
+	MFASecret            string             `bson:"mfa_secret,omitempty" json:"-"`
+	MFAPending           string             `bson:"mfa_pending_secret,omitempty" json:"-"`
+	MFARecovery          []string           `bson:"mfa_recovery_codes,omitempty" json:"-"`
+	MFALastStep          int64              `bson:"mfa_last_step,omitempty" json:"-"`
+	MFAFailures          int                `bson:"mfa_failures,omitempty" json:"-"`
+	MFALockedUntil       *time.Time         `bson:"mfa_locked_until,omitempty" json:"-"`
+	FavouriteGenres      []Genre            `bson:"favourite_genres" json:"favoriteGenres"`
+	Profiles             []Profile          `bson:"profiles,omitempty" json:"profiles,omitempty"`
+	MaxMaturity          *int               `bson:"max_maturity,omitempty" json:"maxMaturity,omitempty"`
+	ParentalPIN          string             `bson:"parental_pin,omitempty" json:"-"`
+}
+
+// FindProfile returns the user's profile with the given id, or nil.
+func (u *User) FindProfile(profileId string) *Profile {
+	for i := range u.Profiles {
+		if u.Profiles[i].ProfileID == profileId {
+			return &u.Profiles[i]
+		}
+	}
+	return nil
+}
+
+// UserRegister represents registration input
+type UserRegister struct {
+	FirstName       string  `json:"firstName" validate:"required,min=2,max=100"`
+	LastName        string  `json:"lastName" validate:"required,min=2,max=100"`
+	Email           string  `json:"email" validate:"required,email"`
+	Password        string  `json:"password" validate:"required,min=8,max=20"`
+	FavouriteGenres []Genre `json:"favoriteGenres" validate:"required,dive"`
 }
 
 // UserLogin represents login input
@@ -28,26 +66,81 @@ type UserLogin struct {
 	Password string `json:"password" validate:"required,min=8,max=20"`
 }
 
-// UserResponse is used in API responses for user info
-type UserResponse struct {
-	UserID          string  `json:"userId"`
-	FirstName       string  `json:"firstName"`
-	LastName        string  `json:"lastName"`
-	Email           string  `json:"email"`
-	Role            string  `json:"role"`
-	Token           *string `json:"token,omitempty"`
-	RefreshToken    *string `json:"refreshToken,omitempty"`
-	FavouriteGenres []Genre `json:"favoriteGenres"`
+// UserUpdate is the PATCH /me body. Fields left out are not changed.
+// Changing the email also needs the current password.
+type UserUpdate struct {
+	FirstName       *string  `json:"firstName" validate:"omitempty,min=2,max=100"`
+	LastName        *string  `json:"lastName" validate:"omitempty,min=2,max=100"`
+	Email           *string  `json:"email" validate:"omitempty,email"`
+	FavouriteGenres *[]Genre `json:"favoriteGenres" validate:"omitempty,min=1"`
+	CurrentPassword string   `json:"current_password"`
 }
 
 // UserContent is the payload inside APIResponse content
 type UserContent struct {
-	UserID         string  `json:"userId"`
-	FirstName      string  `json:"firstName"`
-	LastName       string  `json:"lastName"`
-	Email          string  `json:"email"`
-	Role           string  `json:"role"`
-	Token          *string `json:"token,omitempty"`
-	RefreshToken   *string `json:"refreshToken,omitempty"`
-	FavoriteGenres []Genre `json:"favoriteGenres"`
+	UserID               string     `json:"userId"`
+	FirstName            string     `json:"firstName"`
+	LastName             string     `json:"lastName"`
+	Email                string     `json:"email"`
+	EmailVerified        bool       `json:"emailVerified"`
+	PendingEmail         string     `json:"pendingEmail,omitempty"`
+	Role                 string     `json:"role"`
+	Token                *string    `json:"token,omitempty"`
+	RefreshToken         *string    `json:"refreshToken,omitempty"`
+	FavoriteGenres       []Genre    `json:"favoriteGenres"`
+	DeletionScheduledFor *time.Time `json:"deletionScheduledFor,omitempty"`
+	Profiles             []Profile  `json:"profiles,omitempty"`
+	MaxMaturity          *int       `json:"maxMaturity,omitempty"`
+	ParentalPINSet       bool       `json:"parentalPinSet"`
+}
+
+// NewUserContent returns the public view of a user, without tokens.
+func NewUserContent(user *User) UserContent {
+	return UserContent{
+		UserID:               user.UserID,
+		FirstName:            user.FirstName,
+		LastName:             user.LastName,
+		Email:                user.Email,
+		EmailVerified:        user.EmailVerified,
+		PendingEmail:         user.PendingEmail,
+		Role:                 user.Role,
+		FavoriteGenres:       user.FavouriteGenres,
+		DeletionScheduledFor: user.DeletionScheduledFor,
+		Profiles:             user.Profiles,
+		MaxMaturity:          user.MaxMaturity,
+		ParentalPINSet:       user.ParentalPIN != "",
+	}
+}
+
+// UserSummary is the admin view of an account.
+type UserSummary struct {
+	UserContent
+	Disabled       bool       `json:"disabled"`
+	DisabledAt     *time.Time `json:"disabledAt,omitempty"`
+	DisabledReason string     `json:"disabledReason,omitempty"`
+	MFAEnabled     bool       `json:"mfaEnabled"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+// NewUserSummary returns the admin view of a user.
+func NewUserSummary(user *User) UserSummary {
+	return UserSummary{
+		UserContent:    NewUserContent(user),
+		Disabled:       user.Disabled,
+		DisabledAt:     user.DisabledAt,
+		DisabledReason: user.DisabledReason,
+		MFAEnabled:     user.MFAEnabled,
+		CreatedAt:      user.CreatedAt,
+	}
+}
+
+// UserPage is the paginated envelope returned by GET /admin/users
+type UserPage struct {
+	Users      []UserSummary `json:"users"`
+	Total      int64         `json:"total"`
+	Page       int64         `json:"page"`
+	PageSize   int64         `json:"page_size"`
+	TotalPages int64         `json:"total_pages"`
+	Next       string        `json:"next,omitempty"`
+	Prev       string        `json:"prev,omitempty"`
 }
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Purposes of a UserToken
+const (
+	UserTokenPasswordReset     = "password_reset"
+	UserTokenEmailVerification = "email_verification"
+)
+
+// UserToken is a single-use, time-limited token emailed to a user. Only the
+// hash of the token is stored. Email is the address the token was sent to;
+// verifying it only succeeds while the account still has that address.
+type UserToken struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	TokenHash string             `bson:"token_hash" json:"-"`
+	UserID    string             `bson:"user_id" json:"user_id"`
+	Purpose   string             `bson:"purpose" json:"purpose"`
+	Email     string             `bson:"email" json:"email"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	ExpiresAt time.Time          `bson:"expires_at" json:"expires_at"`
+	UsedAt    *time.Time         `bson:"used_at,omitempty" json:"used_at,omitempty"`
+}
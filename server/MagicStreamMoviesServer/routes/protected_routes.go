@@ -3,14 +3,66 @@ package routes
 import (
 	controller "github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/controllers"
 	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/middleware"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/utils"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func SetupProtectedRoutes(router *gin.Engine, client *mongo.Client) {
 	router.Use(middleware.AuthMiddleWare())
+
+	moviesWrite := middleware.RequirePermission(utils.PermMoviesWrite)
+	reviewsWrite := middleware.RequirePermission(utils.PermReviewsWrite)
+	taxonomyWrite := middleware.RequirePermission(utils.PermTaxonomyWrite)
+	jobsRead := middleware.RequirePermission(utils.PermJobsRead)
+	usersAdmin := middleware.RequirePermission(utils.PermUsersAdmin)
+
 	router.GET("/recommendedmovies", controller.GetRecommendedMovies(client))
 	router.GET("/movie/:imdb_id", controller.GetMovie(client))
-	router.POST("/addmovie", controller.AddMovie(client))
-	router.PATCH("/updatereview/:imdb_id", controller.AdminReviewUpdate(client))
+	router.PUT("/movie/:imdb_id", moviesWrite, controller.UpdateMovie(client))
+	router.PATCH("/movie/:imdb_id", moviesWrite, controller.UpdateMovie(client))
+	router.DELETE("/movie/:imdb_id", moviesWrite, controller.DeleteMovie(client))
+	router.POST("/movie/:imdb_id/restore", moviesWrite, controller.RestoreMovie(client))
+	router.POST("/addmovie", moviesWrite, controller.AddMovie(client))
+	router.POST("/movies/import", moviesWrite, controller.ImportMoviesHandler(client))
+	router.POST("/movies/rerank", reviewsWrite, controller.StartRerank(client))
+	router.GET("/movies/rerank/:run_id", reviewsWrite, controller.GetRerankRun(client))
+	router.POST("/movies/rerank/:run_id/resume", reviewsWrite, controller.ResumeRerank(client))
+	router.PATCH("/updatereview/:imdb_id", reviewsWrite, controller.AdminReviewUpdate(client))
+	router.GET("/jobs/:job_id", jobsRead, controller.GetJob(client))
+	router.POST("/genres", taxonomyWrite, controller.CreateGenre(client))
+	router.PUT("/genres/order", taxonomyWrite, controller.ReorderGenres(client))
+	router.PATCH("/genres/:genre_id", taxonomyWrite, controller.RenameGenre(client))
+	router.DELETE("/genres/:genre_id", taxonomyWrite, controller.DeleteGenre(client))
+	router.POST("/rankings", taxonomyWrite, controller.CreateRanking(client))
+	router.PUT("/rankings/order", taxonomyWrite, controller.ReorderRankings(client))
+	router.PATCH("/rankings/:ranking_name", taxonomyWrite, controller.RenameRanking(client))
+	router.DELETE("/rankings/:ranking_name", taxonomyWrite, controller.DeleteRanking(client))
+	router.POST("/auth/unlock", usersAdmin, controller.UnlockLogin(client))
+	router.GET("/admin/users", usersAdmin, controller.ListUsers(client))
+	router.GET("/admin/users/:user_id", usersAdmin, controller.GetUser(client))
+	router.PATCH("/admin/users/:user_id/role", usersAdmin, controller.SetUserRole(client))
+	router.POST("/admin/users/:user_id/disable", usersAdmin, controller.DisableUser(client))
+	router.POST("/admin/users/:user_id/enable", usersAdmin, controller.EnableUser(client))
+	router.POST("/admin/users/:user_id/logout", usersAdmin, controller.ForceLogoutUser(client))
+	router.POST("/logout", controller.LogoutHandler(client))
+	router.POST("/logout/all", controller.LogoutAllHandler(client))
+	router.GET("/sessions", controller.GetSessions(client))
+	router.DELETE("/sessions/:session_id", controller.RevokeSession(client))
+	router.GET("/me", controller.GetMe(client))
+	router.PATCH("/me", controller.UpdateMe(client))
+	router.DELETE("/me", controller.DeleteMe(client))
+	router.POST("/me/delete/cancel", controller.CancelDeleteMe(client))
+	router.GET("/me/export", controller.ExportMe(client))
+	router.GET("/me/profiles", controller.ListProfiles(client))
+	router.POST("/me/profiles", controller.CreateProfile(client))
+	router.PATCH("/me/profiles/:profile_id", controller.UpdateProfile(client))
+	router.DELETE("/me/profiles/:profile_id", controller.DeleteProfile(client))
+	router.POST("/me/profiles/:profile_id/select", controller.SelectProfile(client))
+	router.PUT("/me/parental-controls", controller.UpdateParentalControls(client))
+	router.POST("/me/password", controller.ChangePassword(client))
+	router.POST("/me/mfa/enroll", controller.EnrollMFA(client))
+	router.POST("/me/mfa/confirm", controller.ConfirmMFA(client))
+	router.POST("/me/mfa/disable", controller.DisableMFA(client))
+	router.POST("/me/mfa/recovery-codes", controller.RegenerateRecoveryCodes(client))
 }
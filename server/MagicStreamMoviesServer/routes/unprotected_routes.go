@@ -2,6 +2,7 @@ package routes
 
 import (
 	controller "github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/controllers"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/middleware"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -10,8 +11,17 @@ func SetupUnProtectedRoutes(router *gin.Engine, client *mongo.Client) {
 
 	router.POST("/register", controller.RegisterUser(client))
 	router.POST("/login", controller.LoginUser(client))
-	router.GET("/movies", controller.GetMovies(client))
-	router.POST("/logout", controller.LogoutHandler(client))
+	router.POST("/login/mfa", controller.LoginMFA(client))
+	router.POST("/login/mfa/enroll", controller.LoginMFAEnroll(client))
+	router.POST("/login/mfa/enroll/confirm", controller.LoginMFAEnrollConfirm(client))
+	router.POST("/password/forgot", controller.ForgotPassword(client))
+	router.POST("/password/reset", controller.ResetPassword(client))
+	router.POST("/email/verify", controller.VerifyEmail(client))
+	router.POST("/email/verify/resend", controller.ResendVerification(client))
+	router.GET("/movies", middleware.OptionalAuthMiddleWare(), controller.GetMovies(client))
+	router.GET("/movies/search", middleware.OptionalAuthMiddleWare(), controller.SearchMovies(client))
 	router.GET("/genres", controller.GetGenres(client))
+	router.GET("/rankings", controller.GetRankingsHandler(client))
 	router.POST("/refresh", controller.RefreshTokenHandler(client))
+	router.GET("/.well-known/jwks.json", controller.GetJWKS())
 }
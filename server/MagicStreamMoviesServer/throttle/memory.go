@@ -0,0 +1,98 @@
+package throttle
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+	// expiresAt is when the entry stops mattering under the policy it was
+	// recorded with; policies differ per key prefix, so sweep uses this.
+	expiresAt time.Time
+}
+
+// MemoryStore keeps counters in process memory. Each instance counts on its
+// own, so use the mongo store when running more than one.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	swept   time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*memoryEntry)}
+}
+
+func (m *MemoryStore) Name() string {
+	return StoreMemory
+}
+
+func (m *MemoryStore) Status(ctx context.Context, key string, policy Policy) (Status, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+	if !ok {
+		return Status{}, nil
+	}
+	return policy.status(entry.failures, entry.lastFailure, entry.lockedUntil, time.Now()), nil
+}
+
+func (m *MemoryStore) RecordFailure(ctx context.Context, key string, policy Policy) (Status, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.sweep(now)
+
+	entry, ok := m.entries[key]
+	if !ok || now.Sub(entry.lastFailure) > policy.Window {
+		entry = &memoryEntry{}
+		m.entries[key] = entry
+	}
+
+	entry.failures++
+	entry.lastFailure = now
+
+	justLocked := false
+	if entry.failures >= policy.MaxFailures && !entry.lockedUntil.After(now) {
+		entry.lockedUntil = now.Add(policy.Lockout)
+		entry.failures = 0
+		justLocked = true
+	}
+	entry.expiresAt = now.Add(policy.Window)
+	if entry.lockedUntil.After(entry.expiresAt) {
+		entry.expiresAt = entry.lockedUntil
+	}
+
+	st := policy.status(entry.failures, entry.lastFailure, entry.lockedUntil, now)
+	st.JustLocked = justLocked
+	return st, nil
+}
+
+func (m *MemoryStore) Reset(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+	return nil
+}
+
+// sweep drops stale entries at most once a minute so the map cannot grow
+// without bound under a spray of random emails.
+func (m *MemoryStore) sweep(now time.Time) {
+	if now.Sub(m.swept) < time.Minute {
+		return
+	}
+	m.swept = now
+
+	for key, entry := range m.entries {
+		if !entry.expiresAt.After(now) {
+			delete(m.entries, key)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package throttle
+
+import (
+	"context"
+	"time"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/database"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type attemptDoc struct {
+	Key         string    `bson:"key"`
+	Failures    int       `bson:"failures"`
+	LastFailure time.Time `bson:"last_failure_at"`
+	LockedUntil time.Time `bson:"locked_until,omitempty"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+}
+
+// MongoStore keeps counters in the login_attempts collection so every
+// instance sees the same failures. Documents expire through a TTL index.
+type MongoStore struct {
+	client *mongo.Client
+}
+
+func NewMongoStore(client *mongo.Client) *MongoStore {
+	return &MongoStore{client: client}
+}
+
+func (m *MongoStore) Name() string {
+	return StoreMongo
+}
+
+func (m *MongoStore) collection() *mongo.Collection {
+	return database.OpenCollection("login_attempts", m.client)
+}
+
+func (m *MongoStore) Status(ctx context.Context, key string, policy Policy) (Status, error) {
+	var doc attemptDoc
+	err := m.collection().FindOne(ctx, bson.M{"key": key}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return Status{}, nil
+	}
+	if err != nil {
+		return Status{}, err
+	}
+	return policy.status(doc.Failures, doc.LastFailure, doc.LockedUntil, time.Now()), nil
+}
+
+func (m *MongoStore) RecordFailure(ctx context.Context, key string, policy Policy) (Status, error) {
+	now := time.Now()
+	windowStart := now.Add(-policy.Window)
+
+	// One pipeline update increments the counter, or restarts it when the
+	// last failure is outside the window, so concurrent failures from
+	// several instances are all counted.
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"key": key,
+			"failures": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{"$last_failure_at", windowStart}},
+				bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$failures", 0}}, 1}},
+				1,
+			}},
+			"last_failure_at": now,
+			"expires_at":      now.Add(policy.Window + policy.Lockout),
+		}}},
+	}
+
+	var doc attemptDoc
+	err := m.collection().FindOneAndUpdate(ctx, bson.M{"key": key}, update,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&doc)
+	if err != nil {
+		return Status{}, err
+	}
+
+	justLocked := false
+	if doc.Failures >= policy.MaxFailures && !doc.LockedUntil.After(now) {
+		// Only the update that wins the race starts the lockout.
+		lockedUntil := now.Add(policy.Lockout)
+		result, err := m.collection().UpdateOne(ctx,
+			bson.M{"key": key, "failures": doc.Failures},
+			bson.M{"$set": bson.M{"locked_until": lockedUntil, "failures": 0}},
+		)
+		if err != nil {
+			return Status{}, err
+		}
+		if result.ModifiedCount == 1 {
+			justLocked = true
+			doc.LockedUntil = lockedUntil
+			doc.Failures = 0
+		}
+	}
+
+	st := policy.status(doc.Failures, doc.LastFailure, doc.LockedUntil, now)
+	st.JustLocked = justLocked
+	return st, nil
+}
+
+func (m *MongoStore) Reset(ctx context.Context, key string) error {
+	_, err := m.collection().DeleteOne(ctx, bson.M{"key": key})
+	return err
+}
@@ -0,0 +1,149 @@
+package throttle
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/utils"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Store tracks failed login attempts per key. Keys are built with EmailKey
+// and IPKey so the email and the client address are limited independently.
+type Store interface {
+	// Status reports whether key may attempt a login now.
+	Status(ctx context.Context, key string, policy Policy) (Status, error)
+	// RecordFailure counts a failed attempt and locks the key once the
+	// policy's limit is reached.
+	RecordFailure(ctx context.Context, key string, policy Policy) (Status, error)
+	// Reset forgets every failure and any lock on key.
+	Reset(ctx context.Context, key string) error
+	Name() string
+}
+
+// Status is the throttling state of one key.
+type Status struct {
+	Failures int
+	// RetryAfter is how long the caller has to wait before the next attempt;
+	// zero means an attempt is allowed now.
+	RetryAfter time.Duration
+	// Locked is set while a lockout is in effect. JustLocked is set only on
+	// the RecordFailure call that started it.
+	Locked      bool
+	JustLocked  bool
+	LockedUntil time.Time
+}
+
+// Policy describes how failures on one kind of key are punished.
+type Policy struct {
+	// MaxFailures within Window lock the key for Lockout.
+	MaxFailures int
+	Window      time.Duration
+	Lockout     time.Duration
+	// From the DelayAfter-th failure on, each further attempt has to wait
+	// BaseDelay, doubling per failure up to MaxDelay.
+	DelayAfter int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// Delay returns how long to wait after the given number of failures.
+func (p Policy) Delay(failures int) time.Duration {
+	if failures < p.DelayAfter || p.BaseDelay <= 0 {
+		return 0
+	}
+	delay := p.BaseDelay
+	for i := p.DelayAfter; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxDelay)
+}
+
+// status derives a Status from stored counters.
+func (p Policy) status(failures int, lastFailure, lockedUntil time.Time, now time.Time) Status {
+	if lockedUntil.After(now) {
+		return Status{Failures: failures, Locked: true, LockedUntil: lockedUntil, RetryAfter: lockedUntil.Sub(now)}
+	}
+	if now.Sub(lastFailure) > p.Window {
+		return Status{}
+	}
+	st := Status{Failures: failures}
+	if wait := lastFailure.Add(p.Delay(failures)).Sub(now); wait > 0 {
+		st.RetryAfter = wait
+	}
+	return st
+}
+
+// EmailKey and IPKey build store keys. Emails are lower-cased so case
+// variations share one counter.
+func EmailKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Policies for the two key kinds. A shared address (an office, a mobile
+// carrier) sees more honest failures than one account, so IPs get a higher
+// limit.
+func EmailPolicy() Policy {
+	return Policy{
+		MaxFailures: utils.EnvInt("LOGIN_MAX_FAILURES", 5),
+		Window:      time.Duration(utils.EnvInt("LOGIN_FAILURE_WINDOW_MINUTES", 15)) * time.Minute,
+		Lockout:     time.Duration(utils.EnvInt("LOGIN_LOCKOUT_MINUTES", 15)) * time.Minute,
+		DelayAfter:  utils.EnvInt("LOGIN_DELAY_AFTER", 3),
+		BaseDelay:   time.Second,
+		MaxDelay:    30 * time.Second,
+	}
+}
+
+func IPPolicy() Policy {
+	policy := EmailPolicy()
+	policy.MaxFailures = utils.EnvInt("LOGIN_MAX_FAILURES_PER_IP", 20)
+	policy.DelayAfter = utils.EnvInt("LOGIN_DELAY_AFTER_PER_IP", 10)
+	return policy
+}
+
+// Supported values for LOGIN_THROTTLE_STORE
+const (
+	StoreMemory = "memory"
+	StoreMongo  = "mongo"
+)
+
+var (
+	defaultStore Store = NewMemoryStore()
+	configureMu  sync.Mutex
+)
+
+// Configure selects the store from LOGIN_THROTTLE_STORE: "memory" (the
+// default) keeps counters in this process, "mongo" shares them between
+// instances through the login_attempts collection.
+func Configure(client *mongo.Client) error {
+	configureMu.Lock()
+	defer configureMu.Unlock()
+
+	switch backend := strings.ToLower(strings.TrimSpace(os.Getenv("LOGIN_THROTTLE_STORE"))); backend {
+	case "", StoreMemory:
+		defaultStore = NewMemoryStore()
+	case StoreMongo:
+		defaultStore = NewMongoStore(client)
+	default:
+		return fmt.Errorf("unknown LOGIN_THROTTLE_STORE %q", backend)
+	}
+
+	log.Println("Login throttle store:", defaultStore.Name())
+	return nil
+}
+
+// Default returns the store chosen by Configure.
+func Default() Store {
+	configureMu.Lock()
+	defer configureMu.Unlock()
+	return defaultStore
+}
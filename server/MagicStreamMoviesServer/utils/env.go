@@ -0,0 +1,19 @@
+package utils
+
+import (
+	"log"
+	"os"
+	"strconv"
+)
+
+// EnvInt reads a positive integer setting from the environment, falling back
+// when it is unset or invalid.
+func EnvInt(name string, fallback int) int {
+	if raw := os.Getenv(name); raw != "" {
+		if val, err := strconv.Atoi(raw); err == nil && val > 0 {
+			return val
+		}
+		log.Println("Invalid", name+":", raw)
+	}
+	return fallback
+}
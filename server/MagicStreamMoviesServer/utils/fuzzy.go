@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// =========================
+// NORMALIZE TEXT FOR FUZZY MATCHING
+// =========================
+// NormalizeText lower-cases s, replaces punctuation with spaces and collapses
+// runs of whitespace.
+func NormalizeText(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// =========================
+// TRIGRAM SIMILARITY
+// =========================
+// Trigrams returns the set of padded character trigrams of every word in s,
+// in the same way as PostgreSQL's pg_trgm.
+func Trigrams(s string) map[string]struct{} {
+	grams := make(map[string]struct{})
+	for _, word := range strings.Fields(NormalizeText(s)) {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			grams[string(padded[i:i+3])] = struct{}{}
+		}
+	}
+	return grams
+}
+
+// TrigramSimilarity returns the Jaccard similarity of the trigram sets of a
+// and b, between 0 (nothing shared) and 1 (identical).
+func TrigramSimilarity(a, b string) float64 {
+	ta, tb := Trigrams(a), Trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for g := range ta {
+		if _, ok := tb[g]; ok {
+			shared++
+		}
+	}
+
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+// =========================
+// EDIT DISTANCE
+// =========================
+// Levenshtein returns the number of single-rune insertions, deletions and
+// substitutions needed to turn a into b.
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 {
+		return len(rb)
+	}
+	if len(rb) == 0 {
+		return len(ra)
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// EditSimilarity scales the Levenshtein distance of the normalized inputs to
+// a score between 0 and 1.
+func EditSimilarity(a, b string) float64 {
+	na, nb := NormalizeText(a), NormalizeText(b)
+	longest := max(len([]rune(na)), len([]rune(nb)))
+	if longest == 0 {
+		return 0
+	}
+	return 1 - float64(Levenshtein(na, nb))/float64(longest)
+}
@@ -0,0 +1,445 @@
+package utils
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Tokens are signed with asymmetric keys kept as PEM files in JWT_KEYS_DIR,
+// one key per file. The file name without ".pem" is the key id ("kid") put
+// in every token header. A kid starting with a UTC timestamp such as
+// "20240131T120000Z-..." records the key's creation time, so copying or
+// restoring the directory does not change it; keys named otherwise count as
+// the oldest. Every key in the directory is trusted for verification and
+// published at /.well-known/jwks.json; the newest published key signs.
+//
+// Supported keys are RSA (RS256) and Ed25519 (EdDSA) in PKCS#8, or RSA in
+// PKCS#1.
+//
+// Configuration:
+//   - JWT_KEYS_DIR: the key directory (required).
+//   - JWT_KEYS_BOOTSTRAP=true: generate a first key if the directory has
+//     none, instead of refusing to start. Meant for development.
+//   - JWT_KEY_ALGORITHM: EdDSA (default) or RS256, for generated keys.
+//   - JWT_KEY_ROTATION_HOURS: when set, a new key is generated once the
+//     signing key is this old, and keys that can no longer have live tokens
+//     are deleted.
+//   - JWT_KEY_PUBLISH_MINUTES: how long a new key is only published before it
+//     starts signing, so other services can fetch it first (default 10).
+
+const (
+	algRS256 = "RS256"
+	algEdDSA = "EdDSA"
+
+	// kidTimeLayout is the creation time prefix of generated kids.
+	kidTimeLayout = "20060102T150405Z"
+
+	// accessTokenTTL is the lifetime of access tokens.
+	accessTokenTTL = 24 * time.Hour
+)
+
+var ErrNoSigningKeys = errors.New("no JWT signing keys found")
+
+type signingKey struct {
+	kid       string
+	alg       string
+	private   crypto.Signer
+	public    crypto.PublicKey
+	createdAt time.Time
+}
+
+func (k *signingKey) method() jwt.SigningMethod {
+	if k.alg == algEdDSA {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
+
+type keyRing struct {
+	mu         sync.RWMutex
+	dir        string
+	keys       map[string]*signingKey
+	lastReload time.Time
+}
+
+var keys = &keyRing{}
+
+// LoadSigningKeys reads the key directory. It must succeed before any token
+// is issued or verified; main refuses to start otherwise.
+func LoadSigningKeys() error {
+	dir := os.Getenv("JWT_KEYS_DIR")
+	if dir == "" {
+		return errors.New("JWT_KEYS_DIR is not set")
+	}
+	keys.dir = dir
+
+	bootstrap := os.Getenv("JWT_KEYS_BOOTSTRAP") == "true"
+	if bootstrap {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return err
+		}
+	}
+
+	err := keys.reload()
+	if errors.Is(err, ErrNoSigningKeys) && bootstrap {
+		kid, genErr := generateKeyFile(dir)
+		if genErr != nil {
+			return genErr
+		}
+		log.Println("Generated first JWT key", kid)
+		err = keys.reload()
+	}
+	if errors.Is(err, ErrNoSigningKeys) {
+		return fmt.Errorf("%w in %s; add a key or set JWT_KEYS_BOOTSTRAP=true", ErrNoSigningKeys, dir)
+	}
+	if err != nil {
+		return err
+	}
+
+	current, err := currentSigningKey()
+	if err != nil {
+		return err
+	}
+	log.Println("Loaded", len(keys.snapshot()), "JWT keys, signing with", current.kid)
+	return nil
+}
+
+// StartKeyRotation periodically re-reads the key directory, so keys added by
+// an operator or another instance are picked up, and rotates keys when
+// JWT_KEY_ROTATION_HOURS is set. It stops when ctx is cancelled.
+func StartKeyRotation(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := rotateKeys(); err != nil {
+					log.Println("JWT key rotation failed:", err)
+				}
+				if err := keys.reload(); err != nil {
+					log.Println("Failed to reload JWT keys:", err)
+				}
+			}
+		}
+	}()
+}
+
+func rotateKeys() error {
+	hours, _ := strconv.Atoi(os.Getenv("JWT_KEY_ROTATION_HOURS"))
+	if hours <= 0 {
+		return nil
+	}
+	interval := time.Duration(hours) * time.Hour
+
+	all := keys.snapshot()
+	if len(all) == 0 {
+		return ErrNoSigningKeys
+	}
+
+	if time.Since(all[0].createdAt) >= interval {
+		kid, err := generateKeyFile(keys.dir)
+		if err != nil {
+			return err
+		}
+		log.Println("Generated JWT key", kid)
+	}
+
+	// A key stops signing once the next newer key has been published for
+	// JWT_KEY_PUBLISH_MINUTES, and is retired once every token it signed
+	// has expired after that. The newest key is never removed, nor is a key
+	// whose successor has no known creation time.
+	retireAfter := keyPublishDelay() + RefreshTokenTTL
+	for i, k := range all[1:] {
+		successor := all[i]
+		if !successor.createdAt.IsZero() && time.Since(successor.createdAt) > retireAfter {
+			if err := os.Remove(filepath.Join(keys.dir, k.kid+".pem")); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			log.Println("Retired JWT key", k.kid)
+		}
+	}
+	return nil
+}
+
+func keyPublishDelay() time.Duration {
+	minutes := 10
+	if raw := os.Getenv("JWT_KEY_PUBLISH_MINUTES"); raw != "" {
+		if val, err := strconv.Atoi(raw); err == nil && val >= 0 {
+			minutes = val
+		}
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// reload replaces the key set with the contents of the key directory. A
+// directory that fails to load leaves the previous keys in place.
+func (r *keyRing) reload() error {
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return err
+	}
+
+	loaded := make(map[string]*signingKey)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".pem") {
+			continue
+		}
+
+		path := filepath.Join(r.dir, entry.Name())
+		key, err := readKeyFile(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		loaded[key.kid] = key
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastReload = time.Now()
+	if len(loaded) == 0 {
+		return ErrNoSigningKeys
+	}
+	r.keys = loaded
+	return nil
+}
+
+// snapshot returns the keys, newest first.
+func (r *keyRing) snapshot() []*signingKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	all := make([]*signingKey, 0, len(r.keys))
+	for _, k := range r.keys {
+		all = append(all, k)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].createdAt.After(all[j].createdAt) })
+	return all
+}
+
+// lookup finds a verification key by kid. An unknown kid may belong to a key
+// another instance just generated, so the directory is re-read, at most once
+// every ten seconds.
+func (r *keyRing) lookup(kid string) (*signingKey, bool) {
+	r.mu.RLock()
+	key, ok := r.keys[kid]
+	stale := time.Since(r.lastReload) > 10*time.Second
+	r.mu.RUnlock()
+
+	if ok || !stale || r.dir == "" {
+		return key, ok
+	}
+	if err := r.reload(); err != nil {
+		log.Println("Failed to reload JWT keys:", err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	key, ok = r.keys[kid]
+	return key, ok
+}
+
+// currentSigningKey returns the newest key that has been published for at
+// least JWT_KEY_PUBLISH_MINUTES. If every key is newer than that, as right
+// after bootstrapping, the oldest one is used.
+func currentSigningKey() (*signingKey, error) {
+	all := keys.snapshot()
+	if len(all) == 0 {
+		return nil, ErrNoSigningKeys
+	}
+
+	delay := keyPublishDelay()
+	for _, k := range all {
+		if time.Since(k.createdAt) >= delay {
+			return k, nil
+		}
+	}
+	return all[len(all)-1], nil
+}
+
+// signToken signs claims with the current key and sets the kid header.
+func signToken(claims jwt.Claims) (string, error) {
+	key, err := currentSigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.method(), claims)
+	token.Header["kid"] = key.kid
+	return token.SignedString(key.private)
+}
+
+// verificationKey is the jwt.Keyfunc for our tokens. It only accepts the
+// algorithm that matches the key named by kid, so a token cannot pick a
+// weaker algorithm than the key was made for.
+func verificationKey(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("token has no kid")
+	}
+
+	key, ok := keys.lookup(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	if t.Method.Alg() != key.alg {
+		return nil, fmt.Errorf("unexpected signing method %s", t.Method.Alg())
+	}
+	return key.public, nil
+}
+
+// =========================
+// KEY FILES
+// =========================
+
+func readKeyFile(path string) (*signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed any
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &signingKey{kid: strings.TrimSuffix(filepath.Base(path), ".pem")}
+	key.createdAt = kidCreatedAt(key.kid)
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		if private.N.BitLen() < 2048 {
+			return nil, errors.New("RSA keys must be at least 2048 bits")
+		}
+		key.alg, key.private, key.public = algRS256, private, &private.PublicKey
+	case ed25519.PrivateKey:
+		key.alg, key.private, key.public = algEdDSA, private, private.Public()
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+
+	return key, nil
+}
+
+// kidCreatedAt reads the creation time from a kid's timestamp prefix. Kids
+// without one get the zero time.
+func kidCreatedAt(kid string) time.Time {
+	if len(kid) < len(kidTimeLayout) {
+		return time.Time{}
+	}
+	created, err := time.Parse(kidTimeLayout, kid[:len(kidTimeLayout)])
+	if err != nil {
+		return time.Time{}
+	}
+	return created
+}
+
+// generateKeyFile writes a new private key to dir and returns its kid. The
+// file is written under a temporary name and renamed, so a concurrent reload
+// never sees half a key.
+func generateKeyFile(dir string) (string, error) {
+	var private any
+	var err error
+	if strings.EqualFold(os.Getenv("JWT_KEY_ALGORITHM"), algRS256) {
+		private, err = rsa.GenerateKey(rand.Reader, 3072)
+	} else {
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return "", err
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	kid := time.Now().UTC().Format(kidTimeLayout) + "-" + hex.EncodeToString(suffix)
+
+	tmp := filepath.Join(dir, "."+kid+".tmp")
+	if err := os.WriteFile(tmp, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, kid+".pem")); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return kid, nil
+}
+
+// =========================
+// JWKS
+// =========================
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS returns the public halves of every trusted key.
+func JWKS() []JWK {
+	b64 := base64.RawURLEncoding.EncodeToString
+
+	all := keys.snapshot()
+	set := make([]JWK, 0, len(all))
+	for _, k := range all {
+		jwk := JWK{Kid: k.kid, Use: "sig", Alg: k.alg}
+		switch public := k.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = b64(public.N.Bytes())
+			jwk.E = b64(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = b64(public)
+		}
+		set = append(set, jwk)
+	}
+	return set
+}
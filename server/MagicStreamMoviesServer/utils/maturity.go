@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxMaturityAge is the highest numeric age a certification may state.
+const MaxMaturityAge = 21
+
+// certificationAges maps the supported rating labels to the minimum viewer
+// age they stand for.
+var certificationAges = map[string]int{
+	"G":     0,
+	"PG":    8,
+	"PG-13": 13,
+	"R":     17,
+	"NC-17": 18,
+}
+
+// ParseCertification accepts a rating label (G, PG, PG-13, R, NC-17) or a
+// numeric minimum age such as "12", and returns the normalized label with
+// the age it stands for.
+func ParseCertification(raw string) (string, int, error) {
+	label := strings.ToUpper(strings.TrimSpace(raw))
+	if age, ok := certificationAges[label]; ok {
+		return label, age, nil
+	}
+
+	age, err := strconv.Atoi(strings.TrimSuffix(label, "+"))
+	if err != nil || age < 0 || age > MaxMaturityAge {
+		return "", 0, fmt.Errorf("unknown certification %q; use G, PG, PG-13, R, NC-17 or an age from 0 to %d", raw, MaxMaturityAge)
+	}
+	return strconv.Itoa(age), age, nil
+}
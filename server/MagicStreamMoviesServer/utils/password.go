@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Stored password hashes start with the algorithm that produced them, e.g.
+// "bcrypt$$2a$12$..." or "argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>".
+// Hashes written before the prefix existed are plain bcrypt.
+//
+// PASSWORD_HASH_ALGORITHM picks the scheme for new hashes (bcrypt by
+// default, or argon2id). BCRYPT_COST and ARGON2_MEMORY_KIB,
+// ARGON2_ITERATIONS and ARGON2_PARALLELISM tune them. A hash made with a
+// different scheme or weaker parameters still verifies, and is reported as
+// needing a rehash so it can be upgraded on the next login.
+const (
+	PasswordAlgorithmBcrypt   = "bcrypt"
+	PasswordAlgorithmArgon2id = "argon2id"
+)
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+}
+
+func passwordAlgorithm() string {
+	switch alg := strings.ToLower(strings.TrimSpace(os.Getenv("PASSWORD_HASH_ALGORITHM"))); alg {
+	case "", PasswordAlgorithmBcrypt:
+		return PasswordAlgorithmBcrypt
+	case PasswordAlgorithmArgon2id:
+		return PasswordAlgorithmArgon2id
+	default:
+		log.Println("Unknown PASSWORD_HASH_ALGORITHM", alg+", using bcrypt")
+		return PasswordAlgorithmBcrypt
+	}
+}
+
+func bcryptCost() int {
+	cost := EnvInt("BCRYPT_COST", bcrypt.DefaultCost)
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		log.Println("BCRYPT_COST out of range:", cost)
+		return bcrypt.DefaultCost
+	}
+	return cost
+}
+
+func configuredArgon2Params() argon2Params {
+	return argon2Params{
+		memory:      uint32(EnvInt("ARGON2_MEMORY_KIB", 64*1024)),
+		iterations:  uint32(EnvInt("ARGON2_ITERATIONS", 3)),
+		parallelism: uint8(min(EnvInt("ARGON2_PARALLELISM", 2), 255)),
+	}
+}
+
+// HashPassword hashes a password with the configured algorithm.
+func HashPassword(password string) (string, error) {
+	if passwordAlgorithm() == PasswordAlgorithmArgon2id {
+		return hashArgon2id(password, configuredArgon2Params())
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost())
+	if err != nil {
+		return "", err
+	}
+	return PasswordAlgorithmBcrypt + "$" + string(hash), nil
+}
+
+// VerifyPassword reports whether password matches the stored hash, and
+// whether the hash should be replaced by HashPassword(password) because the
+// configured algorithm or its parameters have changed.
+func VerifyPassword(stored, password string) (ok bool, needsRehash bool) {
+	switch {
+	case strings.HasPrefix(stored, PasswordAlgorithmArgon2id+"$"):
+		params, salt, key, err := parseArgon2id(stored)
+		if err != nil {
+			log.Println("Malformed argon2id password hash:", err)
+			return false, false
+		}
+		computed := argon2.IDKey([]byte(password), salt, params.iterations, params.memory, params.parallelism, uint32(len(key)))
+		if subtle.ConstantTimeCompare(computed, key) != 1 {
+			return false, false
+		}
+		return true, passwordAlgorithm() != PasswordAlgorithmArgon2id || params != configuredArgon2Params()
+
+	case strings.HasPrefix(stored, PasswordAlgorithmBcrypt+"$"):
+		hash := []byte(strings.TrimPrefix(stored, PasswordAlgorithmBcrypt+"$"))
+		if bcrypt.CompareHashAndPassword(hash, []byte(password)) != nil {
+			return false, false
+		}
+		return true, passwordAlgorithm() != PasswordAlgorithmBcrypt || bcryptBelowCost(hash)
+
+	default:
+		// Legacy hash without a prefix
+		if bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) != nil {
+			return false, false
+		}
+		return true, true
+	}
+}
+
+func bcryptBelowCost(hash []byte) bool {
+	cost, err := bcrypt.Cost(hash)
+	return err != nil || cost < bcryptCost()
+}
+
+func hashArgon2id(password string, params argon2Params) (string, error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, params.iterations, params.memory, params.parallelism, argon2KeyLength)
+
+	return fmt.Sprintf("%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		PasswordAlgorithmArgon2id, argon2.Version,
+		params.memory, params.iterations, params.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func parseArgon2id(stored string) (params argon2Params, salt, key []byte, err error) {
+	parts := strings.Split(stored, "$")
+	if len(parts) != 5 {
+		return params, nil, nil, fmt.Errorf("expected 5 fields, got %d", len(parts))
+	}
+
+	var version int
+	if _, err = fmt.Sscanf(parts[1], "v=%d", &version); err != nil {
+		return params, nil, nil, err
+	}
+	if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2 version %d", version)
+	}
+	if _, err = fmt.Sscanf(parts[2], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil {
+		return params, nil, nil, err
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[3]); err != nil {
+		return params, nil, nil, err
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return params, nil, nil, err
+	}
+	return params, salt, key, nil
+}
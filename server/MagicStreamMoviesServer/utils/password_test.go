@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// usePasswordConfig sets cheap hashing parameters so the tests run quickly.
+func usePasswordConfig(t *testing.T, algorithm string) {
+	t.Setenv("PASSWORD_HASH_ALGORITHM", algorithm)
+	t.Setenv("BCRYPT_COST", "4")
+	t.Setenv("ARGON2_MEMORY_KIB", "1024")
+	t.Setenv("ARGON2_ITERATIONS", "1")
+	t.Setenv("ARGON2_PARALLELISM", "1")
+}
+
+func TestHashPasswordFormat(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		prefix    string
+	}{
+		{"", "bcrypt$$2a$04$"},
+		{"bcrypt", "bcrypt$$2a$04$"},
+		{"argon2id", "argon2id$v=19$m=1024,t=1,p=1$"},
+		{"unknown", "bcrypt$$2a$04$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			usePasswordConfig(t, tt.algorithm)
+
+			hash, err := HashPassword("correct horse")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(hash, tt.prefix) {
+				t.Errorf("hash %q does not start with %q", hash, tt.prefix)
+			}
+		})
+	}
+}
+
+func TestArgon2idRoundTrip(t *testing.T) {
+	params := argon2Params{memory: 1024, iterations: 2, parallelism: 3}
+	hash, err := hashArgon2id("correct horse", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, salt, key, err := parseArgon2id(hash)
+	if err != nil {
+		t.Fatalf("parseArgon2id(%q): %v", hash, err)
+	}
+	if parsed != params {
+		t.Errorf("parsed params %+v, want %+v", parsed, params)
+	}
+	if len(salt) != argon2SaltLength {
+		t.Errorf("salt has %d bytes, want %d", len(salt), argon2SaltLength)
+	}
+	if len(key) != argon2KeyLength {
+		t.Errorf("key has %d bytes, want %d", len(key), argon2KeyLength)
+	}
+
+	again, err := hashArgon2id("correct horse", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again == hash {
+		t.Error("two hashes of the same password are equal; the salt is not random")
+	}
+}
+
+func TestParseArgon2idMalformed(t *testing.T) {
+	for _, stored := range []string{
+		"argon2id",
+		"argon2id$v=19$m=1024,t=1,p=1$c2FsdA",
+		"argon2id$v=16$m=1024,t=1,p=1$c2FsdA$a2V5",
+		"argon2id$v=19$m=x,t=1,p=1$c2FsdA$a2V5",
+		"argon2id$v=19$m=1024,t=1,p=1$not base64!$a2V5",
+		"argon2id$v=19$m=1024,t=1,p=1$c2FsdA$not base64!",
+	} {
+		if _, _, _, err := parseArgon2id(stored); err == nil {
+			t.Errorf("parseArgon2id(%q) succeeded", stored)
+		}
+		if ok, _ := VerifyPassword(stored, "anything"); ok {
+			t.Errorf("VerifyPassword accepted malformed hash %q", stored)
+		}
+	}
+}
+
+func TestVerifyPassword(t *testing.T) {
+	const password = "correct horse"
+
+	usePasswordConfig(t, "bcrypt")
+	bcryptHash, err := HashPassword(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weakBcrypt, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := string(weakBcrypt)
+
+	usePasswordConfig(t, "argon2id")
+	argonHash, err := HashPassword(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	strongArgon, err := hashArgon2id(password, argon2Params{memory: 2048, iterations: 2, parallelism: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		algorithm  string
+		bcryptCost string
+		stored     string
+		password   string
+		wantOK     bool
+		wantRehash bool
+	}{
+		{"bcrypt current", "bcrypt", "4", bcryptHash, password, true, false},
+		{"bcrypt wrong password", "bcrypt", "4", bcryptHash, "wrong", false, false},
+		{"bcrypt below cost", "bcrypt", "5", bcryptHash, password, true, true},
+		{"bcrypt after switching to argon2id", "argon2id", "4", bcryptHash, password, true, true},
+		{"legacy bcrypt", "bcrypt", "4", legacy, password, true, true},
+		{"legacy bcrypt wrong password", "bcrypt", "4", legacy, "wrong", false, false},
+		{"argon2id current", "argon2id", "4", argonHash, password, true, false},
+		{"argon2id wrong password", "argon2id", "4", argonHash, "wrong", false, false},
+		{"argon2id other params", "argon2id", "4", strongArgon, password, true, true},
+		{"argon2id after switching to bcrypt", "bcrypt", "4", argonHash, password, true, true},
+		{"empty hash", "bcrypt", "4", "", password, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usePasswordConfig(t, tt.algorithm)
+			t.Setenv("BCRYPT_COST", tt.bcryptCost)
+
+			ok, rehash := VerifyPassword(tt.stored, tt.password)
+			if ok != tt.wantOK || rehash != tt.wantRehash {
+				t.Errorf("VerifyPassword = %v, %v; want %v, %v", ok, rehash, tt.wantOK, tt.wantRehash)
+			}
+		})
+	}
+}
+
+func TestRehashUpgrades(t *testing.T) {
+	usePasswordConfig(t, "bcrypt")
+	old, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	usePasswordConfig(t, "argon2id")
+	if _, rehash := VerifyPassword(old, "correct horse"); !rehash {
+		t.Fatal("bcrypt hash not flagged for rehash under argon2id")
+	}
+	upgraded, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, rehash := VerifyPassword(upgraded, "correct horse"); !ok || rehash {
+		t.Errorf("upgraded hash: VerifyPassword = %v, %v; want true, false", ok, rehash)
+	}
+}
@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Permissions checked by middleware.RequirePermission.
+const (
+	PermMoviesWrite   = "movies:write"   // add, edit, delete, restore and import movies
+	PermReviewsWrite  = "reviews:write"  // write admin reviews and re-rank the catalog
+	PermTaxonomyWrite = "taxonomy:write" // manage genres and rankings
+	PermJobsRead      = "jobs:read"      // poll background jobs
+	PermUsersAdmin    = "users:admin"    // manage user accounts
+)
+
+// AllPermissions lists every permission, for the "*" wildcard.
+var AllPermissions = []string{
+	PermMoviesWrite,
+	PermReviewsWrite,
+	PermTaxonomyWrite,
+	PermJobsRead,
+	PermUsersAdmin,
+}
+
+// defaultRolePermissions is used when ROLE_PERMISSIONS is not set.
+const defaultRolePermissions = "ADMIN=*;USER="
+
+var (
+	rolePermissionsOnce sync.Once
+	rolePermissions     map[string]map[string]bool
+)
+
+// RolePermissions returns the role to permission mapping. It is read once
+// from ROLE_PERMISSIONS, formatted as "ROLE=perm,perm;ROLE=perm", where "*"
+// grants every permission, e.g.
+//
+//	ADMIN=*;EDITOR=movies:write,reviews:write,jobs:read;USER=
+//
+// Unknown permission names are logged and ignored.
+func RolePermissions() map[string]map[string]bool {
+	rolePermissionsOnce.Do(func() {
+		raw := os.Getenv("ROLE_PERMISSIONS")
+		if raw == "" {
+			raw = defaultRolePermissions
+		}
+		rolePermissions = parseRolePermissions(raw)
+	})
+	return rolePermissions
+}
+
+func parseRolePermissions(raw string) map[string]map[string]bool {
+	known := make(map[string]bool, len(AllPermissions))
+	for _, perm := range AllPermissions {
+		known[perm] = true
+	}
+
+	roles := make(map[string]map[string]bool)
+	for _, entry := range strings.Split(raw, ";") {
+		role, perms, _ := strings.Cut(entry, "=")
+		role = strings.TrimSpace(role)
+		if role == "" {
+			continue
+		}
+
+		granted := make(map[string]bool)
+		for _, perm := range strings.Split(perms, ",") {
+			perm = strings.TrimSpace(perm)
+			switch {
+			case perm == "":
+			case perm == "*":
+				for _, p := range AllPermissions {
+					granted[p] = true
+				}
+			case known[perm]:
+				granted[perm] = true
+			default:
+				log.Println("Ignoring unknown permission", perm, "for role", role)
+			}
+		}
+		roles[role] = granted
+	}
+	return roles
+}
+
+// HasPermission reports whether role grants perm.
+func HasPermission(role, perm string) bool {
+	return RolePermissions()[role][perm]
+}
+
+// IsKnownRole reports whether role is configured in ROLE_PERMISSIONS.
+func IsKnownRole(role string) bool {
+	_, ok := RolePermissions()[role]
+	return ok
+}
+
+// PermissionsForRole returns the permissions role grants, sorted.
+func PermissionsForRole(role string) []string {
+	perms := []string{}
+	for perm := range RolePermissions()[role] {
+		perms = append(perms, perm)
+	}
+	sort.Strings(perms)
+	return perms
+}
+
+// MFARequiredForRole reports whether MFA_REQUIRED_ROLES, a comma separated
+// list such as "ADMIN", requires users with role to enroll in two-factor
+// authentication.
+func MFARequiredForRole(role string) bool {
+	for _, required := range strings.Split(os.Getenv("MFA_REQUIRED_ROLES"), ",") {
+		if strings.TrimSpace(required) == role && role != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,222 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/database"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RefreshTokenTTL is how long a refresh token, and a session that is not
+// refreshed, stays valid.
+const RefreshTokenTTL = 7 * 24 * time.Hour
+
+var (
+	ErrSessionNotFound   = errors.New("session not found")
+	ErrSessionRevoked    = errors.New("session has been revoked")
+	ErrRefreshTokenReuse = errors.New("refresh token has already been used")
+)
+
+const sessionCollection = "sessions"
+
+// HashToken returns the hex SHA-256 of a token. Tokens are long random JWTs,
+// so a fast unsalted hash is enough to make a leaked sessions collection
+// useless.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewSessionID returns a fresh id for the "sid" claim.
+func NewSessionID() string {
+	return primitive.NewObjectID().Hex()
+}
+
+// CreateSession stores a new session whose current refresh token is
+// refreshToken.
+func CreateSession(ctx context.Context, client *mongo.Client, sessionId, userId, refreshToken, userAgent, ip string) error {
+	now := time.Now()
+	session := models.Session{
+		SessionID:        sessionId,
+		UserID:           userId,
+		RefreshTokenHash: HashToken(refreshToken),
+		UserAgent:        userAgent,
+		IPAddress:        ip,
+		CreatedAt:        now,
+		LastUsedAt:       now,
+		ExpiresAt:        now.Add(RefreshTokenTTL),
+	}
+
+	_, err := database.OpenCollection(sessionCollection, client).InsertOne(ctx, session)
+	return err
+}
+
+// RotateSession swaps the session's current refresh token from presented to
+// next. The swap is a single conditional update, so two concurrent refreshes
+// with the same token cannot both succeed.
+//
+// Any validly signed refresh token for the session that is not the current
+// one must be a token that was already rotated away, i.e. a replay. In that
+// case the whole session is revoked, logging out both the attacker and the
+// legitimate device, and ErrRefreshTokenReuse is returned.
+func RotateSession(ctx context.Context, client *mongo.Client, sessionId, presented, next string) error {
+	sessions := database.OpenCollection(sessionCollection, client)
+	now := time.Now()
+
+	result, err := sessions.UpdateOne(ctx,
+		bson.M{
+			"session_id":         sessionId,
+			"refresh_token_hash": HashToken(presented),
+			"revoked_at":         bson.M{"$exists": false},
+			"expires_at":         bson.M{"$gt": now},
+		},
+		bson.M{"$set": bson.M{
+			"refresh_token_hash": HashToken(next),
+			"last_used_at":       now,
+			"expires_at":         now.Add(RefreshTokenTTL),
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 1 {
+		return nil
+	}
+
+	var session models.Session
+	err = sessions.FindOne(ctx, bson.M{"session_id": sessionId}).Decode(&session)
+	if err == mongo.ErrNoDocuments {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if session.RevokedAt != nil || !session.ExpiresAt.After(now) {
+		return ErrSessionRevoked
+	}
+
+	log.Println("Refresh token reuse detected for session", sessionId, "of user", session.UserID)
+	if err := RevokeSession(ctx, client, sessionId, models.SessionRevokedReuse); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReuse
+}
+
+// ReplaceSessionToken makes next the session's current refresh token
+// without presenting the old one. It is for callers that already proved they
+// own the session with its access token, such as switching profiles.
+func ReplaceSessionToken(ctx context.Context, client *mongo.Client, sessionId, userId, next string) error {
+	now := time.Now()
+	result, err := database.OpenCollection(sessionCollection, client).UpdateOne(ctx,
+		bson.M{
+			"session_id": sessionId,
+			"user_id":    userId,
+			"revoked_at": bson.M{"$exists": false},
+			"expires_at": bson.M{"$gt": now},
+		},
+		bson.M{"$set": bson.M{
+			"refresh_token_hash": HashToken(next),
+			"last_used_at":       now,
+			"expires_at":         now.Add(RefreshTokenTTL),
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+// RevokeSession marks a session as revoked. Revoking an already revoked
+// session keeps the original reason.
+func RevokeSession(ctx context.Context, client *mongo.Client, sessionId, reason string) error {
+	_, err := database.OpenCollection(sessionCollection, client).UpdateOne(ctx,
+		bson.M{"session_id": sessionId, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now(), "revoked_reason": reason}},
+	)
+	return err
+}
+
+// IsSessionActive reports whether the session exists and has been neither
+// revoked nor allowed to expire.
+func IsSessionActive(ctx context.Context, client *mongo.Client, sessionId string) (bool, error) {
+	count, err := database.OpenCollection(sessionCollection, client).CountDocuments(ctx, bson.M{
+		"session_id": sessionId,
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": time.Now()},
+	})
+	return count > 0, err
+}
+
+// RevokeAllSessions revokes every active session of the user.
+func RevokeAllSessions(ctx context.Context, client *mongo.Client, userId, reason string) error {
+	_, err := database.OpenCollection(sessionCollection, client).UpdateMany(ctx,
+		bson.M{"user_id": userId, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now(), "revoked_reason": reason}},
+	)
+	return err
+}
+
+// RevokeOtherSessions revokes every active session of the user except
+// keepSessionId.
+func RevokeOtherSessions(ctx context.Context, client *mongo.Client, userId, keepSessionId, reason string) error {
+	_, err := database.OpenCollection(sessionCollection, client).UpdateMany(ctx,
+		bson.M{
+			"user_id":    userId,
+			"session_id": bson.M{"$ne": keepSessionId},
+			"revoked_at": bson.M{"$exists": false},
+		},
+		bson.M{"$set": bson.M{"revoked_at": time.Now(), "revoked_reason": reason}},
+	)
+	return err
+}
+
+// ListActiveSessions returns the user's sessions that are neither revoked nor
+// expired, most recently used first.
+func ListActiveSessions(ctx context.Context, client *mongo.Client, userId string) ([]models.Session, error) {
+	cursor, err := database.OpenCollection(sessionCollection, client).Find(ctx,
+		bson.M{
+			"user_id":    userId,
+			"revoked_at": bson.M{"$exists": false},
+			"expires_at": bson.M{"$gt": time.Now()},
+		},
+		options.Find().SetSort(bson.D{{Key: "last_used_at", Value: -1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []models.Session{}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// RevokeUserSession revokes one of the user's own sessions. It returns
+// ErrSessionNotFound if the session does not belong to the user or is
+// already revoked.
+func RevokeUserSession(ctx context.Context, client *mongo.Client, userId, sessionId string) error {
+	result, err := database.OpenCollection(sessionCollection, client).UpdateOne(ctx,
+		bson.M{"session_id": sessionId, "user_id": userId, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now(), "revoked_reason": models.SessionRevokedByUser}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
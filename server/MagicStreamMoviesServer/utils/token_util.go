@@ -3,15 +3,17 @@ package utils
 import (
 	"context"
 	"errors"
-	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/database"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // =========================
@@ -23,60 +25,86 @@ type SignedDetails struct {
 	LastName  string `json:"last_name"`
 	Role      string `json:"role"`
 	UserId    string `json:"user_id"`
+	SessionId string `json:"sid,omitempty"`
+	// ProfileId is the household profile selected on this session, if any.
+	ProfileId string `json:"pid,omitempty"`
+	// TokenVersion must match users.token_version; bumping that field
+	// invalidates every token issued before.
+	TokenVersion int `json:"tv"`
+	// TokenUse is "access" or "refresh". Both kinds are signed with the same
+	// keys, so this is what stops a refresh token being used as an access
+	// token and the other way round.
+	TokenUse string `json:"token_use"`
 	jwt.RegisteredClaims
 }
 
-// Load secrets
-var SECRET_KEY = os.Getenv("SECRET_KEY")
-var SECRET_REFRESH_KEY = os.Getenv("SECRET_REFRESH_KEY")
+// Values of SignedDetails.TokenUse
+const (
+	TokenUseAccess  = "access"
+	TokenUseRefresh = "refresh"
+	// TokenUseMFA is the short-lived token issued after the password check
+	// for accounts with two-factor authentication.
+	TokenUseMFA = "mfa"
+	// TokenUseMFAEnroll is issued instead when the account's role requires
+	// two-factor authentication but the user has not enrolled yet.
+	TokenUseMFAEnroll = "mfa_enroll"
+)
 
-func init() {
-	if SECRET_KEY == "" || SECRET_REFRESH_KEY == "" {
-		fmt.Println("⚠ WARNING: JWT SECRET KEYS ARE NOT SET IN ENVIRONMENT VARIABLES")
-	}
-}
+// mfaTokenTTL is how long a user has to enter their code after the password.
+const mfaTokenTTL = 5 * time.Minute
+
+const tokenIssuer = "MagicStream"
 
 // =========================
 // GENERATE ACCESS + REFRESH TOKENS
 // =========================
-func GenerateAllTokens(email, firstName, lastName, role, userId string) (string, string, error) {
+func GenerateAllTokens(email, firstName, lastName, role, userId, sessionId, profileId string, tokenVersion int) (string, string, error) {
 
 	// ACCESS TOKEN
 	accessClaims := SignedDetails{
-		Email:     email,
-		FirstName: firstName,
-		LastName:  lastName,
-		Role:      role,
-		UserId:    userId,
+		Email:        email,
+		FirstName:    firstName,
+		LastName:     lastName,
+		Role:         role,
+		UserId:       userId,
+		SessionId:    sessionId,
+		ProfileId:    profileId,
+		TokenVersion: tokenVersion,
+		TokenUse:     TokenUseAccess,
 		RegisteredClaims: jwt.RegisteredClaims{
-			Issuer:    "MagicStream",
+			Issuer:    tokenIssuer,
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)), // 1 day
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(accessTokenTTL)), // 1 day
 		},
 	}
 
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	signedAccessToken, err := accessToken.SignedString([]byte(SECRET_KEY))
+	signedAccessToken, err := signToken(accessClaims)
 	if err != nil {
 		return "", "", err
 	}
 
 	// REFRESH TOKEN
 	refreshClaims := SignedDetails{
-		Email:     email,
-		FirstName: firstName,
-		LastName:  lastName,
-		Role:      role,
-		UserId:    userId,
+		Email:        email,
+		FirstName:    firstName,
+		LastName:     lastName,
+		Role:         role,
+		UserId:       userId,
+		SessionId:    sessionId,
+		ProfileId:    profileId,
+		TokenVersion: tokenVersion,
+		TokenUse:     TokenUseRefresh,
 		RegisteredClaims: jwt.RegisteredClaims{
-			Issuer:    "MagicStream",
+			// A unique id keeps two refresh tokens issued in the same second
+			// distinct, which rotation relies on.
+			ID:        primitive.NewObjectID().Hex(),
+			Issuer:    tokenIssuer,
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(7 * 24 * time.Hour)), // 7 days
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(RefreshTokenTTL)), // 7 days
 		},
 	}
 
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	signedRefreshToken, err := refreshToken.SignedString([]byte(SECRET_REFRESH_KEY))
+	signedRefreshToken, err := signToken(refreshClaims)
 	if err != nil {
 		return "", "", err
 	}
@@ -84,118 +112,223 @@ func GenerateAllTokens(email, firstName, lastName, role, userId string) (string,
 	return signedAccessToken, signedRefreshToken, nil
 }
 
-// =========================
-// UPDATE TOKENS IN MONGODB
-// =========================
-func UpdateAllTokens(userId, token, refreshToken string, client *mongo.Client) error {
-	userCollection := database.OpenCollection("users", client)
+// GenerateMFAToken issues a token that proves the password step of the
+// login succeeded. use is TokenUseMFA or TokenUseMFAEnroll.
+func GenerateMFAToken(userId string, tokenVersion int, use string) (string, error) {
+	claims := SignedDetails{
+		UserId:       userId,
+		TokenVersion: tokenVersion,
+		TokenUse:     use,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        primitive.NewObjectID().Hex(),
+			Issuer:    tokenIssuer,
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(mfaTokenTTL)),
+		},
+	}
+	return signToken(claims)
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
-	defer cancel()
+// ValidateMFAToken checks a token from GenerateMFAToken.
+func ValidateMFAToken(tokenString, use string) (*SignedDetails, error) {
+	claims, err := parseToken(tokenString, use)
+	if err != nil {
+		return nil, errors.New("invalid or expired MFA token")
+	}
 
-	update := bson.M{"$set": bson.M{
-		"token":         token,
-		"refresh_token": refreshToken,
-		"updated_at":    time.Now(),
-	}}
+	if err := checkRevoked(claims); err != nil {
+		return nil, err
+	}
 
-	_, err := userCollection.UpdateOne(ctx, bson.M{"user_id": userId}, update)
-	return err
+	return claims, nil
 }
 
 // =========================
-// GET ACCESS TOKEN FROM COOKIE
+// GET ACCESS TOKEN FROM COOKIE OR HEADER
 // =========================
+
+// GetAccessToken returns the access token from the access_token cookie or an
+// "Authorization: Bearer" header. When a request carries both, the source
+// named by AUTH_TOKEN_PRECEDENCE ("cookie", the default, or "header") wins.
 func GetAccessToken(c *gin.Context) (string, error) {
+	cookieToken, _ := c.Cookie("access_token")
+	headerToken := BearerToken(c)
+
+	if os.Getenv("AUTH_TOKEN_PRECEDENCE") == "header" {
+		if headerToken != "" {
+			return headerToken, nil
+		}
+		if cookieToken != "" {
+			return cookieToken, nil
+		}
+	} else {
+		if cookieToken != "" {
+			return cookieToken, nil
+		}
+		if headerToken != "" {
+			return headerToken, nil
+		}
+	}
 
-	tokenString, err := c.Cookie("access_token")
-	if err != nil {
-		return "", errors.New("missing access_token cookie")
+	return "", errors.New("missing access token cookie or bearer token")
+}
+
+// BearerToken returns the token from an "Authorization: Bearer <jwt>"
+// header, or "" if there is none.
+func BearerToken(c *gin.Context) string {
+	scheme, token, found := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return ""
 	}
+	return strings.TrimSpace(token)
+}
 
-	return tokenString, nil
+// TokenMode reports whether the client asked for tokens in the response body
+// instead of cookies, with an "X-Token-Mode: body" header or ?token_mode=body.
+// Browsers keep using the HttpOnly cookies; token mode is for the mobile app
+// and scripts.
+func TokenMode(c *gin.Context) bool {
+	return strings.EqualFold(c.GetHeader("X-Token-Mode"), "body") || strings.EqualFold(c.Query("token_mode"), "body")
 }
 
 // =========================
 // VALIDATE ACCESS TOKEN
 // =========================
 func ValidateToken(tokenString string) (*SignedDetails, error) {
-	claims := &SignedDetails{}
-
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
-		return []byte(SECRET_KEY), nil
-	})
-
+	claims, err := parseToken(tokenString, TokenUseAccess)
 	if err != nil {
 		return nil, errors.New("invalid access token")
 	}
 
-	if !token.Valid {
-		return nil, errors.New("invalid access token")
-	}
-
 	if claims.ExpiresAt.Time.Before(time.Now()) {
 		return nil, errors.New("access token expired")
 	}
 
+	if err := checkRevoked(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// parseToken verifies the signature against the key named by the kid header
+// and checks the issuer and the token_use claim.
+func parseToken(tokenString, use string) (*SignedDetails, error) {
+	claims := &SignedDetails{}
+
+	token, err := jwt.ParseWithClaims(tokenString, claims, verificationKey,
+		jwt.WithValidMethods([]string{algRS256, algEdDSA}),
+		jwt.WithIssuer(tokenIssuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid || claims.TokenUse != use {
+		return nil, errors.New("invalid token")
+	}
+
 	return claims, nil
 }
 
 // =========================
-// GET ROLE FROM TOKEN (COOKIE)
+// GET ROLE / USER ID FROM CONTEXT
 // =========================
+
+// GetRoleFromContext returns the role AuthMiddleWare stored for the request.
 func GetRoleFromContext(c *gin.Context) (string, error) {
-	tokenString, err := GetAccessToken(c)
-	if err != nil {
-		return "", err
+	role := c.GetString("role")
+	if role == "" {
+		return "", errors.New("role not found in context")
 	}
+	return role, nil
+}
 
-	claims, err := ValidateToken(tokenString)
-	if err != nil {
-		return "", err
+// GetUserIdFromContext returns the user id AuthMiddleWare stored for the
+// request.
+func GetUserIdFromContext(c *gin.Context) (string, error) {
+	userId := c.GetString("userId")
+	if userId == "" {
+		return "", errors.New("user id not found in context")
 	}
-
-	return claims.Role, nil
+	return userId, nil
 }
 
 // =========================
-// GET USER ID FROM TOKEN (COOKIE)
+// VALIDATE REFRESH TOKEN
 // =========================
-func GetUserIdFromContext(c *gin.Context) (string, error) {
-	tokenString, err := GetAccessToken(c)
+func ValidateRefreshToken(tokenString string) (*SignedDetails, error) {
+	claims, err := parseToken(tokenString, TokenUseRefresh)
 	if err != nil {
-		return "", err
+		return nil, errors.New("invalid refresh token")
 	}
 
-	claims, err := ValidateToken(tokenString)
-	if err != nil {
-		return "", err
+	if claims.ExpiresAt.Time.Before(time.Now()) {
+		return nil, errors.New("refresh token expired")
+	}
+
+	if err := checkRevoked(claims); err != nil {
+		return nil, err
 	}
 
-	return claims.UserId, nil
+	return claims, nil
 }
 
 // =========================
-// VALIDATE REFRESH TOKEN
+// TOKEN REVOCATION
 // =========================
-func ValidateRefreshToken(tokenString string) (*SignedDetails, error) {
-	claims := &SignedDetails{}
 
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
-		return []byte(SECRET_REFRESH_KEY), nil
-	})
+// ErrAccountDisabled is returned for tokens of an account an admin has
+// disabled.
+var ErrAccountDisabled = errors.New("account is disabled")
+
+// checkRevoked rejects tokens of disabled accounts, tokens issued before the
+// user's last "log out all devices" and tokens whose session has been logged
+// out. Lookup failures reject the token too.
+func checkRevoked(claims *SignedDetails) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
+	var user struct {
+		TokenVersion int  `bson:"token_version"`
+		Disabled     bool `bson:"disabled"`
+	}
+	err := database.OpenCollection("users", database.Shared()).FindOne(ctx,
+		bson.M{"user_id": claims.UserId},
+		options.FindOne().SetProjection(bson.M{"token_version": 1, "disabled": 1}),
+	).Decode(&user)
 	if err != nil {
-		return nil, errors.New("invalid refresh token")
+		return errors.New("token user not found")
 	}
-
-	if !token.Valid {
-		return nil, errors.New("invalid refresh token")
+	if user.Disabled {
+		return ErrAccountDisabled
+	}
+	if user.TokenVersion != claims.TokenVersion {
+		return errors.New("token has been revoked")
 	}
 
-	if claims.ExpiresAt.Time.Before(time.Now()) {
-		return nil, errors.New("refresh token expired")
+	if claims.SessionId != "" {
+		active, err := IsSessionActive(ctx, database.Shared(), claims.SessionId)
+		if err != nil || !active {
+			return errors.New("session has been revoked")
+		}
 	}
 
-	return claims, nil
+	return nil
+}
+
+// BumpTokenVersion invalidates every access and refresh token issued to the
+// user so far.
+func BumpTokenVersion(ctx context.Context, client *mongo.Client, userId string) error {
+	result, err := database.OpenCollection("users", client).UpdateOne(ctx,
+		bson.M{"user_id": userId},
+		bson.M{"$inc": bson.M{"token_version": 1}, "$set": bson.M{"updated_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("user not found")
+	}
+	return nil
 }
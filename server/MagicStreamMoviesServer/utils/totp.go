@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP parameters (RFC 6238). These are the defaults every authenticator app
+// understands, so they are not configurable.
+const (
+	totpDigits = 6
+	totpPeriod = 30 * time.Second
+
+	// TOTPSkew is how many 30 second steps either side of the server's clock
+	// are accepted.
+	TOTPSkew = 1
+)
+
+var base32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a new random 160-bit secret, base32 encoded.
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return base32NoPadding.EncodeToString(secret), nil
+}
+
+// TOTPProvisioningURI returns the otpauth:// URI authenticator apps scan as a
+// QR code.
+func TOTPProvisioningURI(secret, accountName string) string {
+	const issuer = "MagicStream"
+
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(int(totpPeriod.Seconds())))
+
+	label := url.PathEscape(issuer + ":" + accountName)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// TOTPCode returns the code for the time step containing t.
+func TOTPCode(secret string, t time.Time) (string, error) {
+	return hotp(secret, totpStep(t))
+}
+
+// VerifyTOTP checks code against the steps within TOTPSkew of now. It returns
+// the matched step so callers can refuse a step that was already used, which
+// stops a code from being replayed within its 30 seconds.
+func VerifyTOTP(secret, code string, now time.Time) (int64, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := totpStep(now)
+	for offset := int64(-TOTPSkew); offset <= TOTPSkew; offset++ {
+		expected, err := hotp(secret, current+offset)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return current + offset, true
+		}
+	}
+	return 0, false
+}
+
+func totpStep(t time.Time) int64 {
+	return t.Unix() / int64(totpPeriod.Seconds())
+}
+
+// hotp implements RFC 4226 with HMAC-SHA1.
+func hotp(secret string, counter int64) (string, error) {
+	key, err := base32NoPadding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", err
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000), nil
+}
+
+// GenerateRecoveryCodes returns n one-time recovery codes such as
+// "k7qm-2xpa-f9tn" together with the hashes to store.
+func GenerateRecoveryCodes(n int) (codes []string, hashes []string, err error) {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+	for i := 0; i < n; i++ {
+		raw := make([]byte, 12)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+
+		var b strings.Builder
+		for j, r := range raw {
+			if j > 0 && j%4 == 0 {
+				b.WriteByte('-')
+			}
+			b.WriteByte(alphabet[int(r)%len(alphabet)])
+		}
+
+		code := b.String()
+		codes = append(codes, code)
+		hashes = append(hashes, HashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode normalizes a recovery code as typed by the user, with or
+// without its dashes and spaces, and hashes it.
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(code)))
+	return HashToken(normalized)
+}
@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the ASCII key "12345678901234567890" used by the test vectors
+// in RFC 4226 and RFC 6238, base32 encoded.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestHOTPRFC4226(t *testing.T) {
+	want := []string{
+		"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489",
+	}
+	for counter, expected := range want {
+		got, err := hotp(rfcSecret, int64(counter))
+		if err != nil {
+			t.Fatalf("hotp(%d): %v", counter, err)
+		}
+		if got != expected {
+			t.Errorf("hotp(%d) = %s, want %s", counter, got, expected)
+		}
+	}
+}
+
+// The RFC 6238 SHA-1 vectors are 8 digits; a 6 digit code is their last six.
+func TestTOTPCodeRFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := TOTPCode(rfcSecret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatalf("TOTPCode(%d): %v", tt.unix, err)
+		}
+		if got != tt.code {
+			t.Errorf("TOTPCode(%d) = %s, want %s", tt.unix, got, tt.code)
+		}
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := totpStep(now)
+	codeAt := func(offset int64) string {
+		code, err := hotp(rfcSecret, step+offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		wantOK   bool
+		wantStep int64
+	}{
+		{"current step", codeAt(0), true, step},
+		{"previous step within skew", codeAt(-1), true, step - 1},
+		{"next step within skew", codeAt(1), true, step + 1},
+		{"too old", codeAt(-TOTPSkew - 1), false, 0},
+		{"too new", codeAt(TOTPSkew + 1), false, 0},
+		{"spaces are ignored", " " + codeAt(0)[:3] + " " + codeAt(0)[3:] + " ", true, step},
+		{"wrong length", codeAt(0)[:5], false, 0},
+		{"empty", "", false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := VerifyTOTP(rfcSecret, tt.code, now)
+			if ok != tt.wantOK || gotStep != tt.wantStep {
+				t.Errorf("VerifyTOTP(%q) = %d, %v; want %d, %v", tt.code, gotStep, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+// Callers refuse a step at or before the last one used, so the step returned
+// for the same code must not change while it stays valid.
+func TestVerifyTOTPReplayStep(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, err := TOTPCode(rfcSecret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, ok := VerifyTOTP(rfcSecret, code, now)
+	if !ok {
+		t.Fatal("code rejected")
+	}
+	again, ok := VerifyTOTP(rfcSecret, code, now.Add(totpPeriod))
+	if !ok {
+		t.Fatal("code rejected one step later")
+	}
+	if again != first {
+		t.Errorf("same code matched step %d, then %d", first, again)
+	}
+}
+
+func TestVerifyTOTPBadSecret(t *testing.T) {
+	if _, ok := VerifyTOTP("not base32!", "123456", time.Now()); ok {
+		t.Error("accepted a code for an invalid secret")
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := base32NoPadding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("secret %q is not base32: %v", secret, err)
+	}
+	if len(key) != 20 {
+		t.Errorf("secret has %d bytes, want 20", len(key))
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != 10 || len(hashes) != 10 {
+		t.Fatalf("got %d codes and %d hashes, want 10 each", len(codes), len(hashes))
+	}
+
+	seen := make(map[string]bool)
+	for i, code := range codes {
+		if len(code) != 14 || strings.Count(code, "-") != 2 {
+			t.Errorf("code %q is not formatted like xxxx-xxxx-xxxx", code)
+		}
+		if seen[code] {
+			t.Errorf("duplicate code %q", code)
+		}
+		seen[code] = true
+
+		typed := []string{
+			code,
+			strings.ToUpper(code),
+			strings.ReplaceAll(code, "-", ""),
+			strings.ReplaceAll(code, "-", " "),
+			"  " + code + "\n",
+		}
+		for _, variant := range typed {
+			if HashRecoveryCode(variant) != hashes[i] {
+				t.Errorf("HashRecoveryCode(%q) does not match the hash of %q", variant, code)
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/database"
+	"github.com/M-oses340/MagicStream254/server/MagicStreamMoviesServer/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrInvalidUserToken covers unknown, expired and already used tokens alike.
+var ErrInvalidUserToken = errors.New("invalid or expired token")
+
+const userTokenCollection = "user_tokens"
+
+// IssueUserToken creates a single-use token for purpose and returns the raw
+// value to email. Earlier unused tokens for the same user and purpose stop
+// working, so only the most recent email's link is valid.
+func IssueUserToken(ctx context.Context, client *mongo.Client, userId, purpose, email string, ttl time.Duration) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	tokens := database.OpenCollection(userTokenCollection, client)
+
+	if _, err := tokens.DeleteMany(ctx, bson.M{
+		"user_id": userId,
+		"purpose": purpose,
+		"used_at": bson.M{"$exists": false},
+	}); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	_, err := tokens.InsertOne(ctx, models.UserToken{
+		TokenHash: HashToken(token),
+		UserID:    userId,
+		Purpose:   purpose,
+		Email:     email,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// ConsumeUserToken marks a token as used and returns it. The check and the
+// update are one operation, so a token can only ever be redeemed once.
+func ConsumeUserToken(ctx context.Context, client *mongo.Client, token, purpose string) (*models.UserToken, error) {
+	now := time.Now()
+
+	var userToken models.UserToken
+	err := database.OpenCollection(userTokenCollection, client).FindOneAndUpdate(ctx,
+		bson.M{
+			"token_hash": HashToken(token),
+			"purpose":    purpose,
+			"used_at":    bson.M{"$exists": false},
+			"expires_at": bson.M{"$gt": now},
+		},
+		bson.M{"$set": bson.M{"used_at": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&userToken)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrInvalidUserToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &userToken, nil
+}